@@ -0,0 +1,129 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind classifies an error, it decides the response status and is sent as the machine-readable code.
+type Kind string
+
+const (
+	KindBadRequest           Kind = "bad_request"
+	KindUnauthorized         Kind = "unauthorized"
+	KindForbidden            Kind = "forbidden"
+	KindNotFound             Kind = "not_found"
+	KindConflict             Kind = "conflict"
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindPreconditionRequired Kind = "precondition_required"
+	KindValidation           Kind = "validation_failed"
+	KindUnprocessable        Kind = "unprocessable"
+	KindUnsupportedMediaType Kind = "unsupported_media_type"
+	KindTooLarge             Kind = "too_large"
+	KindGone                 Kind = "gone"
+	KindInternal             Kind = "internal"
+)
+
+var statuses = map[Kind]int{
+	KindBadRequest:           http.StatusBadRequest,
+	KindUnauthorized:         http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindPreconditionRequired: http.StatusPreconditionRequired,
+	KindValidation:           http.StatusUnprocessableEntity,
+	KindUnprocessable:        http.StatusUnprocessableEntity,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindTooLarge:             http.StatusRequestEntityTooLarge,
+	KindGone:                 http.StatusGone,
+	KindInternal:             http.StatusInternalServerError,
+}
+
+// Error is an error the API can explain to its caller. Fields holds the rejected fields of a
+// validation error, Err the underlying cause which is logged but never sent.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Extensions describes the error to GraphQL callers the way the problem document does for REST.
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.Kind, "status": e.Status()}
+	if len(e.Fields) > 0 {
+		extensions["errors"] = e.Fields
+	}
+	return extensions
+}
+
+// Status is the HTTP status answering the error.
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func BadRequest(format string, args ...interface{}) *Error {
+	return New(KindBadRequest, format, args...)
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return New(KindNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return New(KindConflict, format, args...)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(KindForbidden, format, args...)
+}
+
+// Validation reports the rejected fields, each mapped to the reason.
+func Validation(fields map[string]string) *Error {
+	return &Error{Kind: KindValidation, Message: "validation failed", Fields: fields}
+}
+
+// Internal hides err from the caller behind a generic message.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Message: "internal server error", Err: err}
+}
+
+// Wrap classifies err as kind, keeping its message.
+func Wrap(kind Kind, err error) *Error {
+	return &Error{Kind: kind, Message: err.Error(), Err: err}
+}
+
+// From returns err as an *Error, errors that were never classified are internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
+
+// Is tells whether err is an *Error of the kind.
+func Is(err error, kind Kind) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Kind == kind
+}
@@ -0,0 +1,34 @@
+package apperrors
+
+import "net/http"
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document, Code and RequestId are extension members.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      Kind              `json:"code"`
+	RequestId string            `json:"requestId,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// NewProblem describes err for the request to instance.
+func NewProblem(err error, instance string, requestId string) Problem {
+
+	appErr := From(err)
+	status := appErr.Status()
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    appErr.Error(),
+		Instance:  instance,
+		Code:      appErr.Kind,
+		RequestId: requestId,
+		Errors:    appErr.Fields,
+	}
+}
@@ -0,0 +1,98 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testApplication/interfaces"
+	"testApplication/utils"
+)
+
+type local struct {
+	root string
+}
+
+func InitStore() *local {
+
+	root := utils.Conf.GetString("attachments.local.path")
+
+	err := os.MkdirAll(root, 0750)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return &local{root: root}
+}
+
+func (store *local) path(key string) (string, error) {
+
+	path := filepath.Join(store.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(store.root)+string(filepath.Separator)) {
+		return "", errors.New("invalid blob key " + key)
+	}
+	return path, nil
+}
+
+func (store *local) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	// write next to the target and rename, readers never see a half written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, content)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (store *local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, interfaces.ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (store *local) Delete(ctx context.Context, key string) error {
+
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testApplication/interfaces"
+	"testing"
+)
+
+func TestPutGetDelete(t *testing.T) {
+
+	ctx := context.Background()
+	store := &local{root: t.TempDir()}
+	key := "clients/1/report.txt"
+
+	err := store.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello" {
+		t.Errorf("content = %q, want hello", content)
+	}
+
+	err = store.Delete(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, key)
+	if !errors.Is(err, interfaces.ErrBlobNotFound) {
+		t.Errorf("get after delete: err = %v, want ErrBlobNotFound", err)
+	}
+
+	// deleting a missing blob is not an error
+	err = store.Delete(ctx, key)
+	if err != nil {
+		t.Errorf("second delete: %s", err)
+	}
+}
+
+func TestKeyOutsideRoot(t *testing.T) {
+
+	store := &local{root: t.TempDir()}
+
+	err := store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+	if err == nil {
+		t.Error("put outside the root succeeded")
+	}
+}
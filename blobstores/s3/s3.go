@@ -0,0 +1,77 @@
+package s3
+
+import (
+	"context"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"log"
+	"testApplication/interfaces"
+	"testApplication/utils"
+)
+
+// s3 stores blobs in any S3-compatible service, a local MinIO works as a stand-in for development.
+type s3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func InitStore() *s3 {
+
+	endpoint := utils.Conf.GetString("attachments.s3.endpoint")
+	accessKey := utils.Conf.GetString("attachments.s3.accessKey")
+	secretKey := utils.Conf.GetString("attachments.s3.secretKey")
+	bucket := utils.Conf.GetString("attachments.s3.bucket")
+	useSSL := utils.Conf.GetBool("attachments.s3.useSSL")
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exists, err := client.BucketExists(context.TODO(), bucket)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !exists {
+		err = client.MakeBucket(context.TODO(), bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return &s3{client: client, bucket: bucket}
+}
+
+func (store *s3) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+
+	_, err := store.client.PutObject(ctx, store.bucket, key, content, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (store *s3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	object, err := store.client.GetObject(ctx, store.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, stat surfaces a missing key before anything is streamed
+	_, err = object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, interfaces.ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (store *s3) Delete(ctx context.Context, key string) error {
+	return store.client.RemoveObject(ctx, store.bucket, key, minio.RemoveObjectOptions{})
+}
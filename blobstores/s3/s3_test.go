@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testApplication/interfaces"
+	"testApplication/utils"
+	"testing"
+)
+
+// fakeS3 answers the few S3 calls the store makes from memory, objects are keyed by "/bucket/key".
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string][]byte
+}
+
+func (fake *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if _, ok := r.URL.Query()["location"]; ok {
+		w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+		return
+	}
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !fake.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			fake.buckets[bucket] = true
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+
+	if !fake.buckets[bucket] {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		content, err := readPayload(r)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		fake.objects[r.URL.Path] = content
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		content, ok := fake.objects[r.URL.Path]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		if r.Method == http.MethodGet {
+			w.Write(content)
+		}
+	case http.MethodDelete:
+		delete(fake.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readPayload reads an upload, which is sent in signed chunks over plain http.
+func readPayload(r *http.Request) ([]byte, error) {
+
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var content bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return content.Bytes(), nil
+		}
+		_, err = io.CopyN(&content, reader, size)
+		if err != nil {
+			return nil, err
+		}
+		_, err = reader.Discard(2)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(`<Error><Code>` + code + `</Code><Message>` + code + `</Message></Error>`))
+}
+
+// testStore connects to the S3-compatible service at S3_TEST_ENDPOINT, a MinIO for example, and to an
+// in-memory fake when that is unset.
+func testStore(t *testing.T) *s3 {
+
+	utils.Conf = viper.New()
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		server := httptest.NewServer(&fakeS3{buckets: map[string]bool{}, objects: map[string][]byte{}})
+		t.Cleanup(server.Close)
+		endpoint = strings.TrimPrefix(server.URL, "http://")
+	}
+	utils.Conf.Set("attachments.s3.endpoint", endpoint)
+	utils.Conf.Set("attachments.s3.accessKey", envOr("S3_TEST_ACCESS_KEY", "minioadmin"))
+	utils.Conf.Set("attachments.s3.secretKey", envOr("S3_TEST_SECRET_KEY", "minioadmin"))
+	utils.Conf.Set("attachments.s3.bucket", envOr("S3_TEST_BUCKET", "attachments-test"))
+	utils.Conf.Set("attachments.s3.useSSL", os.Getenv("S3_TEST_USE_SSL") == "true")
+
+	return InitStore()
+}
+
+func envOr(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestPutGetDelete(t *testing.T) {
+
+	ctx := context.Background()
+	store := testStore(t)
+	key := "clients/1/report.txt"
+
+	err := store.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello" {
+		t.Errorf("content = %q, want hello", content)
+	}
+
+	err = store.Delete(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(ctx, key)
+	if !errors.Is(err, interfaces.ErrBlobNotFound) {
+		t.Errorf("get after delete: err = %v, want ErrBlobNotFound", err)
+	}
+}
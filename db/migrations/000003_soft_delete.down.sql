@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users_deleted_at_idx;
+
+DROP INDEX IF EXISTS clients_deleted_at_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS clients_deleted_at_idx ON clients (deleted_at);
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS clients_tags_idx;
+
+DROP INDEX IF EXISTS clients_status_idx;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS tax_id,
+    DROP COLUMN IF EXISTS address,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS email;
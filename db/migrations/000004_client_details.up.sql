@@ -0,0 +1,15 @@
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS email      VARCHAR(255)             NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS phone      VARCHAR(16)              NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS address    TEXT                     NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tax_id     VARCHAR(64)              NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status     VARCHAR(16)              NOT NULL DEFAULT 'lead'
+        CONSTRAINT clients_status_check
+            CHECK (status IN ('lead', 'active', 'archived')),
+    ADD COLUMN IF NOT EXISTS tags       TEXT[]                   NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS clients_status_idx ON clients (status);
+
+CREATE INDEX IF NOT EXISTS clients_tags_idx ON clients USING GIN (tags);
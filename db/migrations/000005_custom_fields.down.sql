@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS clients_custom_fields_idx;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS custom_fields;
+
+DROP TABLE IF EXISTS custom_fields;
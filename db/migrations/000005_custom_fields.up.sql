@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS custom_fields
+(
+    id          INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT custom_fields_pkey
+            PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL
+        CONSTRAINT custom_fields_name_key
+            UNIQUE,
+    type        VARCHAR(16)  NOT NULL
+        CONSTRAINT custom_fields_type_check
+            CHECK (type IN ('string', 'number', 'date', 'enum', 'bool')),
+    required    BOOLEAN      NOT NULL DEFAULT FALSE,
+    enum_values TEXT[]       NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS clients_custom_fields_idx ON clients USING GIN (custom_fields);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS contacts
+(
+    id         INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT contacts_pkey
+            PRIMARY KEY,
+    client_id  INTEGER                  NOT NULL,
+    name       VARCHAR(255)             NOT NULL,
+    email      VARCHAR(255)             NOT NULL DEFAULT '',
+    phone      VARCHAR(16)              NOT NULL DEFAULT '',
+    position   VARCHAR(255)             NOT NULL DEFAULT '',
+    is_primary BOOLEAN                  NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_client
+        FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS contacts_client_id_idx ON contacts (client_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS contacts_primary_idx ON contacts (client_id) WHERE is_primary AND deleted_at IS NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS activities
+(
+    id         INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT activities_pkey
+            PRIMARY KEY,
+    client_id  INTEGER                  NOT NULL,
+    author_id  INTEGER                  NOT NULL,
+    kind       VARCHAR(16)              NOT NULL
+        CONSTRAINT activities_kind_check
+            CHECK (kind IN ('call', 'meeting', 'comment')),
+    body       TEXT                     NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_client
+        FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS activities_timeline_idx ON activities (client_id, created_at DESC, id DESC);
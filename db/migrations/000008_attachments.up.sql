@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id           INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT attachments_pkey
+            PRIMARY KEY,
+    client_id    INTEGER                  NOT NULL,
+    file_name    VARCHAR(255)             NOT NULL,
+    content_type VARCHAR(255)             NOT NULL,
+    size         BIGINT                   NOT NULL,
+    checksum     CHAR(64)                 NOT NULL,
+    storage_key  VARCHAR(512)             NOT NULL
+        CONSTRAINT attachments_storage_key_key
+            UNIQUE,
+    uploaded_by  INTEGER                  NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    deleted_at   TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_client
+        FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS attachments_client_id_idx ON attachments (client_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS clients_name_trgm_idx;
+
+DROP INDEX IF EXISTS clients_search_vector_idx;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+                setweight(to_tsvector('simple', name), 'A') ||
+                setweight(to_tsvector('simple', translate(email, '@.', '  ') || ' ' || tax_id), 'B') ||
+                setweight(to_tsvector('simple', address), 'C')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS clients_search_vector_idx ON clients USING GIN (search_vector);
+
+CREATE INDEX IF NOT EXISTS clients_name_trgm_idx ON clients USING GIN (name gin_trgm_ops);
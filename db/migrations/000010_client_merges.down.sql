@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS clients_tax_id_idx;
+
+DROP TABLE IF EXISTS client_merges;
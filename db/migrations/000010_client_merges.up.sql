@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS client_merges
+(
+    id        INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT client_merges_pkey
+            PRIMARY KEY,
+    target_id INTEGER                  NOT NULL,
+    source_id INTEGER                  NOT NULL,
+    merged_by INTEGER                  NOT NULL,
+    merged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    source    JSONB                    NOT NULL,
+    CONSTRAINT fk_target
+        FOREIGN KEY (target_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS client_merges_target_id_idx ON client_merges (target_id);
+
+CREATE INDEX IF NOT EXISTS clients_tax_id_idx ON clients (tax_id) WHERE tax_id <> '';
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         INTEGER GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT webhooks_pkey
+            PRIMARY KEY,
+    url        TEXT                     NOT NULL,
+    events     TEXT[]                   NOT NULL,
+    secret     VARCHAR(255)             NOT NULL,
+    active     BOOLEAN                  NOT NULL DEFAULT TRUE,
+    created_by INTEGER                  NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               BIGINT GENERATED ALWAYS AS IDENTITY
+        CONSTRAINT webhook_deliveries_pkey
+            PRIMARY KEY,
+    webhook_id       INTEGER                  NOT NULL,
+    event_id         VARCHAR(64)              NOT NULL,
+    event_type       VARCHAR(64)              NOT NULL,
+    payload          JSONB                    NOT NULL,
+    status           VARCHAR(16)              NOT NULL DEFAULT 'pending',
+    attempts         INTEGER                  NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_status_code INTEGER                  NOT NULL DEFAULT 0,
+    last_error       TEXT                     NOT NULL DEFAULT '',
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    delivered_at     TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_webhook
+        FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id DESC);
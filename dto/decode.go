@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/validation"
+)
+
+// Decode reads exactly one JSON document into input and applies its declarative rules. Unknown fields,
+// values of the wrong type and rule violations come back as validation.FieldErrors, a body that is not
+// JSON at all as a bad request.
+func Decode(r io.Reader, input interface{}) error {
+
+	err := DecodeStrict(r, input)
+	if err != nil {
+		return err
+	}
+	return validation.Struct(input)
+}
+
+// DecodeStrict reads like Decode but leaves the rules to the caller, for inputs that are only
+// complete once merged with stored values.
+func DecodeStrict(r io.Reader, input interface{}) error {
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(input)
+	if err != nil {
+		return decodeError(err)
+	}
+	if decoder.Decode(&json.RawMessage{}) != io.EOF {
+		return apperrors.BadRequest("the body must hold a single JSON document")
+	}
+	return nil
+}
+
+// arrayIndex matches the array positions encoding/json writes as path segments, tags.0 is reported as tags[0].
+var arrayIndex = regexp.MustCompile(`\.(\d+)`)
+
+func decodeError(err error) error {
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == io.EOF:
+		return apperrors.BadRequest("the body is empty")
+	case errors.As(err, &typeErr):
+		field := arrayIndex.ReplaceAllString(typeErr.Field, "[$1]")
+		return validation.FieldErrors{field: "must be " + jsonType(typeErr.Type.Kind().String())}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return validation.FieldErrors{field: "is not a known field"}
+	}
+	return apperrors.Wrap(apperrors.KindBadRequest, err)
+}
+
+func jsonType(kind string) string {
+
+	switch {
+	case kind == "string":
+		return "a string"
+	case kind == "bool":
+		return "a boolean"
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "an integer"
+	case strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "slice", kind == "array":
+		return "an array"
+	case kind == "map", kind == "struct":
+		return "an object"
+	}
+	return "a " + kind
+}
@@ -0,0 +1,184 @@
+package dto
+
+import "testApplication/models"
+
+// ClientInput is the body creating a client, the server owns id, version and timestamps.
+type ClientInput struct {
+	Name         string                 `json:"name" validate:"notblank,max=255"`
+	Email        string                 `json:"email" validate:"omitempty,email"`
+	Phone        string                 `json:"phone" validate:"omitempty,e164"`
+	Address      string                 `json:"address" validate:"max=1000"`
+	TaxId        string                 `json:"taxId" validate:"max=64"`
+	Status       string                 `json:"status" validate:"omitempty,oneof=lead active archived"`
+	Tags         []string               `json:"tags" validate:"max=100,dive,notblank,max=64"`
+	CustomFields map[string]interface{} `json:"customFields"`
+}
+
+func (input ClientInput) Model() models.Client {
+	return models.Client{
+		Name:         input.Name,
+		Email:        input.Email,
+		Phone:        input.Phone,
+		Address:      input.Address,
+		TaxId:        input.TaxId,
+		Status:       input.Status,
+		Tags:         input.Tags,
+		CustomFields: input.CustomFields,
+	}.WithDefaults()
+}
+
+// ClientUpdateInput replaces the fields of a client it holds, a non-zero version has to match the stored one.
+type ClientUpdateInput struct {
+	Id      int `json:"id" validate:"gt=0"`
+	Version int `json:"version" validate:"gte=0"`
+	ClientInput
+}
+
+func (input ClientUpdateInput) Model() models.Client {
+	client := input.ClientInput.Model()
+	client.Id, client.Version = input.Id, input.Version
+	return client
+}
+
+// ClientInputOf is the input that leaves client as it is.
+func ClientInputOf(client models.Client) ClientInput {
+	return ClientInput{
+		Name:         client.Name,
+		Email:        client.Email,
+		Phone:        client.Phone,
+		Address:      client.Address,
+		TaxId:        client.TaxId,
+		Status:       client.Status,
+		Tags:         client.Tags,
+		CustomFields: client.CustomFields,
+	}
+}
+
+// Over applies the given fields of the input to current, the fields a caller left out keep their
+// stored values instead of being blanked.
+func (input ClientUpdateInput) Over(current models.Client, fields []string) ClientUpdateInput {
+
+	merged := ClientUpdateInput{Id: input.Id, Version: input.Version, ClientInput: ClientInputOf(current)}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			merged.Name = input.Name
+		case "email":
+			merged.Email = input.Email
+		case "phone":
+			merged.Phone = input.Phone
+		case "address":
+			merged.Address = input.Address
+		case "taxId":
+			merged.TaxId = input.TaxId
+		case "status":
+			merged.Status = input.Status
+		case "tags":
+			merged.Tags = input.Tags
+		case "customFields":
+			merged.CustomFields = input.CustomFields
+		}
+	}
+	return merged
+}
+
+type MergeInput struct {
+	TargetId int `json:"targetId" validate:"gt=0"`
+	SourceId int `json:"sourceId" validate:"gt=0,nefield=TargetId"`
+}
+
+type ContactInput struct {
+	Name     string `json:"name" validate:"notblank,max=255"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Phone    string `json:"phone" validate:"omitempty,e164"`
+	Position string `json:"position" validate:"max=255"`
+	Primary  bool   `json:"primary"`
+}
+
+func (input ContactInput) Model() models.Contact {
+	return models.Contact{
+		Name:     input.Name,
+		Email:    input.Email,
+		Phone:    input.Phone,
+		Position: input.Position,
+		Primary:  input.Primary,
+	}
+}
+
+type ActivityInput struct {
+	Kind string `json:"kind" validate:"oneof=call meeting comment"`
+	Body string `json:"body" validate:"notblank,max=10000"`
+}
+
+func (input ActivityInput) Model() models.Activity {
+	return models.Activity{Kind: input.Kind, Body: input.Body}
+}
+
+type CustomFieldInput struct {
+	Name       string   `json:"name" validate:"notblank,max=63"`
+	Type       string   `json:"type" validate:"oneof=string number date enum bool"`
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enumValues" validate:"dive,notblank"`
+}
+
+func (input CustomFieldInput) Model() models.CustomField {
+	return models.CustomField{Name: input.Name, Type: input.Type, Required: input.Required, EnumValues: input.EnumValues}
+}
+
+type CustomFieldUpdateInput struct {
+	Id int `json:"id" validate:"gt=0"`
+	CustomFieldInput
+}
+
+func (input CustomFieldUpdateInput) Model() models.CustomField {
+	field := input.CustomFieldInput.Model()
+	field.Id = input.Id
+	return field
+}
+
+type UserInput struct {
+	Name     string `json:"name" validate:"notblank,max=255"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+func (input UserInput) Model() models.User {
+	return models.User{Name: input.Name, Email: input.Email, Password: input.Password}
+}
+
+type UserUpdateInput struct {
+	Id   int    `json:"id" validate:"gt=0"`
+	Name string `json:"name" validate:"notblank,max=255"`
+}
+
+func (input UserUpdateInput) Model() models.User {
+	return models.User{Id: input.Id, Name: input.Name}
+}
+
+// WebhookInput subscribes a URL to event types, a secret is generated when none is given.
+type WebhookInput struct {
+	Url    string   `json:"url" validate:"httpurl,max=2048"`
+	Events []string `json:"events" validate:"min=1,dive,oneof=client.created client.updated client.deleted user.created user.updated user.deleted"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Active *bool    `json:"active"`
+}
+
+func (input WebhookInput) Model() models.Webhook {
+	webhook := models.Webhook{Url: input.Url, Events: input.Events, Secret: input.Secret, Active: true}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	return webhook
+}
+
+// WebhookUpdateInput replaces the url, events and active flag of a webhook, its secret can not be changed.
+type WebhookUpdateInput struct {
+	Id     int      `json:"id" validate:"gt=0"`
+	Url    string   `json:"url" validate:"httpurl,max=2048"`
+	Events []string `json:"events" validate:"min=1,dive,oneof=client.created client.updated client.deleted user.created user.updated user.deleted"`
+	Active bool     `json:"active"`
+}
+
+func (input WebhookUpdateInput) Model() models.Webhook {
+	return models.Webhook{Id: input.Id, Url: input.Url, Events: input.Events, Active: input.Active}
+}
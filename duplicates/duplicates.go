@@ -0,0 +1,237 @@
+package duplicates
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/query"
+	"testApplication/search"
+)
+
+// Threshold is the score from which two clients are reported as likely duplicates.
+const Threshold = 0.8
+
+// candidatesLimit caps the prefiltered clients scored against one client.
+const candidatesLimit = 50
+
+// scanBatch is how many clients Scan reads at once.
+const scanBatch = 100
+
+const (
+	ReasonTaxId       = "taxId"
+	ReasonName        = "name"
+	ReasonSimilarName = "similarName"
+	ReasonEmailDomain = "emailDomain"
+)
+
+// legalForms are dropped from names, "ACME Inc." and "Acme" are the same company.
+var legalForms = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"ltd": true, "limited": true, "llc": true, "llp": true, "plc": true, "lp": true,
+	"gmbh": true, "ag": true, "kg": true, "ug": true, "sa": true, "sas": true, "sarl": true,
+	"srl": true, "spa": true, "bv": true, "nv": true, "oy": true, "ab": true, "as": true, "the": true,
+}
+
+// freeMailDomains say nothing about the company behind an address.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "yahoo.com": true, "outlook.com": true, "hotmail.com": true,
+	"live.com": true, "icloud.com": true, "me.com": true, "aol.com": true, "proton.me": true,
+	"protonmail.com": true, "gmx.com": true, "gmx.de": true, "mail.ru": true, "yandex.ru": true,
+}
+
+// NameTerms are the lowercase words of a company name without its legal form.
+func NameTerms(name string) []string {
+
+	var terms []string
+	for _, term := range search.Terms(name) {
+		if !legalForms[term] {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// NormalizeName is the name reduced to what tells companies apart.
+func NormalizeName(name string) string {
+	return strings.Join(NameTerms(name), " ")
+}
+
+// EmailDomain is the lowercase domain of a company address, empty for free mail providers.
+func EmailDomain(email string) string {
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	if freeMailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
+func normalizeTaxId(taxId string) string {
+	return strings.Join(search.Terms(taxId), "")
+}
+
+// nameSimilarity compares names both ways, so that one name being a part of the other is not enough.
+func nameSimilarity(a, b string) float64 {
+
+	aTerms, bTerms := NameTerms(a), NameTerms(b)
+	forward, backward := search.Rank(aTerms, strings.Join(bTerms, " ")), search.Rank(bTerms, strings.Join(aTerms, " "))
+	if forward < backward {
+		return forward
+	}
+	return backward
+}
+
+// Match scores how likely duplicate is the same company as client.
+func Match(client models.Client, duplicate models.Client) models.DuplicateMatch {
+
+	match := models.DuplicateMatch{Client: duplicate, Reasons: []string{}}
+
+	if taxId := normalizeTaxId(client.TaxId); taxId != "" && taxId == normalizeTaxId(duplicate.TaxId) {
+		match.Score = 1
+		match.Reasons = append(match.Reasons, ReasonTaxId)
+	}
+
+	if name := NormalizeName(client.Name); name != "" && name == NormalizeName(duplicate.Name) {
+		match.Score = 1
+		match.Reasons = append(match.Reasons, ReasonName)
+	} else if similarity := nameSimilarity(client.Name, duplicate.Name); similarity >= search.MinSimilarity {
+		if similarity > match.Score {
+			match.Score = similarity
+		}
+		match.Reasons = append(match.Reasons, ReasonSimilarName)
+	}
+
+	// a shared company domain backs up the other signals, on its own it may be a subsidiary
+	if domain := EmailDomain(client.Email); domain != "" && domain == EmailDomain(duplicate.Email) {
+		match.Score += 0.3
+		if match.Score > 1 {
+			match.Score = 1
+		}
+		match.Reasons = append(match.Reasons, ReasonEmailDomain)
+	}
+
+	return match
+}
+
+// Find returns the likely duplicates of a client, best first.
+func Find(ctx context.Context, repo interfaces.ClientRepo, client models.Client) ([]models.DuplicateMatch, error) {
+
+	candidates, err := repo.DuplicateCandidates(ctx, models.DuplicateQuery{
+		ExcludeId:   client.Id,
+		NameTerms:   NameTerms(client.Name),
+		TaxId:       normalizeTaxId(client.TaxId),
+		EmailDomain: EmailDomain(client.Email),
+	}, candidatesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []models.DuplicateMatch{}
+	for _, candidate := range candidates {
+		if match := Match(client, candidate); match.Score >= Threshold {
+			matches = append(matches, match)
+		}
+	}
+	sortMatches(matches)
+	return matches, nil
+}
+
+// Scan walks the clients in id order starting after the given id and groups each one with its
+// likely duplicates of a higher id, so every pair is reported once. It stops after limit groups
+// or scanLimit clients and returns the id to continue from, zero once every client was scanned.
+func Scan(ctx context.Context, repo interfaces.ClientRepo, after int, limit int, scanLimit int) ([]models.DuplicateGroup, int, error) {
+
+	groups := []models.DuplicateGroup{}
+	for scanned := 0; scanned < scanLimit; {
+
+		clients, err := repo.GetClients(ctx, models.ListQuery{
+			Limit:  scanBatch,
+			Filter: query.Condition{Field: "id", Type: query.Integer, Op: query.Gt, Value: after},
+			Sort:   []query.Sort{{Field: "id"}},
+		})
+		if err != nil {
+			return groups, 0, err
+		}
+		if len(clients) == 0 {
+			return groups, 0, nil
+		}
+
+		for _, client := range clients {
+			after = client.Id
+			scanned++
+
+			matches, err := Find(ctx, repo, client)
+			if err != nil {
+				return groups, 0, err
+			}
+
+			group := models.DuplicateGroup{Client: client, Duplicates: []models.DuplicateMatch{}}
+			for _, match := range matches {
+				if match.Client.Id > client.Id {
+					group.Duplicates = append(group.Duplicates, match)
+				}
+			}
+			if len(group.Duplicates) > 0 {
+				groups = append(groups, group)
+			}
+			if len(groups) == limit || scanned == scanLimit {
+				return groups, after, nil
+			}
+		}
+		if len(clients) < scanBatch {
+			return groups, 0, nil
+		}
+	}
+	return groups, after, nil
+}
+
+// Merge folds source into target: target values win, the ones it lacks are taken from source.
+func Merge(target models.Client, source models.Client) models.Client {
+
+	merged := target.WithDefaults()
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&merged.Email, source.Email)
+	fill(&merged.Phone, source.Phone)
+	fill(&merged.Address, source.Address)
+	fill(&merged.TaxId, source.TaxId)
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, tag := range append(append([]string{}, merged.Tags...), source.Tags...) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	merged.Tags = tags
+
+	customFields := map[string]interface{}{}
+	for name, value := range source.CustomFields {
+		customFields[name] = value
+	}
+	for name, value := range merged.CustomFields {
+		customFields[name] = value
+	}
+	merged.CustomFields = customFields
+
+	return merged
+}
+
+func sortMatches(matches []models.DuplicateMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Client.Id < matches[j].Client.Id
+	})
+}
@@ -0,0 +1,149 @@
+// Package events carries client and user changes from wherever they are made to the parts of the
+// application reacting to them.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	ClientCreated = "client.created"
+	ClientUpdated = "client.updated"
+	ClientDeleted = "client.deleted"
+	UserCreated   = "user.created"
+	UserUpdated   = "user.updated"
+	UserDeleted   = "user.deleted"
+)
+
+var Types = []string{ClientCreated, ClientUpdated, ClientDeleted, UserCreated, UserUpdated, UserDeleted}
+
+// Event is a change that happened, Data is the record after it, or only its id for a delete.
+// Table names the grant a receiver needs to see it.
+type Event struct {
+	Id         string          `json:"id"`
+	Type       string          `json:"type"`
+	Table      string          `json:"table"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func New(eventType string, table string, data interface{}) (Event, error) {
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	random := make([]byte, 16)
+	_, err = rand.Read(random)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Id:         hex.EncodeToString(random),
+		Type:       eventType,
+		Table:      table,
+		OccurredAt: time.Now().UTC(),
+		Data:       encoded,
+	}, nil
+}
+
+// Bus hands every published event to the subscribers in the order they subscribed. Subscribers run
+// on the publishing goroutine, one that may block has to hand the event off to its own goroutine,
+// SubscribeQueued does that.
+type Bus struct {
+	mu          sync.RWMutex
+	nextId      int
+	subscribers map[int]func(Event)
+	order       []int
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: map[int]func(Event){}}
+}
+
+// Subscribe adds handler and returns the function removing it again.
+func (bus *Bus) Subscribe(handler func(Event)) (unsubscribe func()) {
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	id := bus.nextId
+	bus.nextId++
+	bus.subscribers[id] = handler
+	bus.order = append(bus.order, id)
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		delete(bus.subscribers, id)
+		for i, subscribed := range bus.order {
+			if subscribed == id {
+				bus.order = append(bus.order[:i:i], bus.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// SubscribeQueued runs handler on a goroutine of its own, for subscribers doing I/O. Up to size events
+// wait for it, publishers only block once that many are queued so no event is lost.
+func (bus *Bus) SubscribeQueued(size int, handler func(Event)) (unsubscribe func()) {
+
+	queue := make(chan Event, size)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event := <-queue:
+				handler(event)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	unsubscribeHandler := bus.Subscribe(func(event Event) {
+		select {
+		case queue <- event:
+		case <-done:
+		}
+	})
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unsubscribeHandler()
+			close(done)
+		})
+	}
+}
+
+func (bus *Bus) Publish(event Event) {
+
+	bus.mu.RLock()
+	handlers := make([]func(Event), 0, len(bus.order))
+	for _, id := range bus.order {
+		handlers = append(handlers, bus.subscribers[id])
+	}
+	bus.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// publish builds and publishes an event, a change that already happened is not undone when that fails.
+func (bus *Bus) publish(eventType string, table string, data interface{}) {
+
+	event, err := New(eventType, table, data)
+	if err != nil {
+		log.Printf("events: building %s failed: %s", eventType, err)
+		return
+	}
+	bus.Publish(event)
+}
@@ -0,0 +1,204 @@
+package events
+
+import (
+	"context"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"time"
+)
+
+// clientRepo publishes an event for every client change made through it, so REST, GraphQL and gRPC
+// report changes the same way.
+type clientRepo struct {
+	interfaces.ClientRepo
+	bus *Bus
+}
+
+func Clients(repo interfaces.ClientRepo, bus *Bus) interfaces.ClientRepo {
+	return &clientRepo{ClientRepo: repo, bus: bus}
+}
+
+// reference names a client that is gone or could not be read back.
+type reference struct {
+	Id int `json:"id"`
+}
+
+func (repo *clientRepo) CreateClient(ctx context.Context, client models.Client) (models.Client, error) {
+
+	client, err := repo.ClientRepo.CreateClient(ctx, client)
+	if err == nil {
+		repo.bus.publish(ClientCreated, "clients", client)
+	}
+	return client, err
+}
+
+func (repo *clientRepo) UpdateClient(ctx context.Context, client models.Client) error {
+
+	err := repo.ClientRepo.UpdateClient(ctx, client)
+	if err == nil {
+		repo.updated(ctx, client.Id)
+	}
+	return err
+}
+
+func (repo *clientRepo) PatchClient(ctx context.Context, client models.Client, fields []string) error {
+
+	err := repo.ClientRepo.PatchClient(ctx, client, fields)
+	if err == nil {
+		repo.updated(ctx, client.Id)
+	}
+	return err
+}
+
+func (repo *clientRepo) DeleteClient(ctx context.Context, id int, version int) error {
+
+	err := repo.ClientRepo.DeleteClient(ctx, id, version)
+	if err == nil {
+		repo.bus.publish(ClientDeleted, "clients", reference{Id: id})
+	}
+	return err
+}
+
+func (repo *clientRepo) RestoreClient(ctx context.Context, id int) (models.Client, error) {
+
+	client, err := repo.ClientRepo.RestoreClient(ctx, id)
+	if err == nil {
+		repo.bus.publish(ClientUpdated, "clients", client)
+	}
+	return client, err
+}
+
+func (repo *clientRepo) MergeClients(ctx context.Context, target models.Client, sourceId int, mergedBy int) (models.ClientMerge, error) {
+
+	merge, err := repo.ClientRepo.MergeClients(ctx, target, sourceId, mergedBy)
+	if err == nil {
+		repo.updated(ctx, target.Id)
+		repo.bus.publish(ClientDeleted, "clients", reference{Id: sourceId})
+	}
+	return merge, err
+}
+
+func (repo *clientRepo) BatchClients(ctx context.Context, operations []models.ClientOperation, atomic bool) ([]models.ClientOperationResult, error) {
+
+	results, err := repo.ClientRepo.BatchClients(ctx, operations, atomic)
+	if err != nil && atomic {
+		return results, err
+	}
+	for _, result := range results {
+		if result.Error != "" || result.Errors != nil {
+			continue
+		}
+		switch result.Op {
+		case models.ClientOpCreate:
+			if result.Client != nil {
+				repo.bus.publish(ClientCreated, "clients", *result.Client)
+			}
+		case models.ClientOpUpdate:
+			if result.Client != nil {
+				repo.bus.publish(ClientUpdated, "clients", *result.Client)
+			}
+		case models.ClientOpDelete:
+			repo.bus.publish(ClientDeleted, "clients", reference{Id: result.Id})
+		}
+	}
+	return results, err
+}
+
+// ImportClients reports every imported client as created once the import is committed.
+func (repo *clientRepo) ImportClients(ctx context.Context, clients []models.Client, progress func(imported int)) ([]models.Client, error) {
+
+	imported, err := repo.ClientRepo.ImportClients(ctx, clients, progress)
+	if err == nil {
+		for _, client := range imported {
+			repo.bus.publish(ClientCreated, "clients", client)
+		}
+	}
+	return imported, err
+}
+
+// updated reports the client as stored after the change, the update calls do not return it.
+func (repo *clientRepo) updated(ctx context.Context, id int) {
+
+	client, err := repo.ClientRepo.GetClientById(ctx, id, false)
+	if err != nil {
+		repo.bus.publish(ClientUpdated, "clients", reference{Id: id})
+		return
+	}
+	repo.bus.publish(ClientUpdated, "clients", client)
+}
+
+// user is what events tell about a user, never the password hash.
+type user struct {
+	Id        int        `json:"id"`
+	Email     string     `json:"email"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+func userData(changed models.User) user {
+	return user{Id: changed.Id, Email: changed.Email, Name: changed.Name, DeletedAt: changed.DeletedAt}
+}
+
+// userRepo publishes an event for every user change made through it.
+type userRepo struct {
+	interfaces.UserRepo
+	bus *Bus
+}
+
+func Users(repo interfaces.UserRepo, bus *Bus) interfaces.UserRepo {
+	return &userRepo{UserRepo: repo, bus: bus}
+}
+
+func (repo *userRepo) CreateUser(ctx context.Context, newUser models.User) (models.User, error) {
+
+	created, err := repo.UserRepo.CreateUser(ctx, newUser)
+	if err == nil {
+		repo.bus.publish(UserCreated, "users", userData(created))
+	}
+	return created, err
+}
+
+func (repo *userRepo) UpdateUser(ctx context.Context, changed models.User) (models.User, error) {
+
+	updated, err := repo.UserRepo.UpdateUser(ctx, changed)
+	if err == nil {
+		repo.bus.publish(UserUpdated, "users", userData(updated))
+	}
+	return updated, err
+}
+
+func (repo *userRepo) PatchUser(ctx context.Context, changed models.User, fields []string) (models.User, error) {
+
+	updated, err := repo.UserRepo.PatchUser(ctx, changed, fields)
+	if err == nil {
+		repo.bus.publish(UserUpdated, "users", userData(updated))
+	}
+	return updated, err
+}
+
+func (repo *userRepo) DeleteUser(ctx context.Context, id int) (models.User, error) {
+
+	removed, err := repo.UserRepo.DeleteUser(ctx, id)
+	if err == nil {
+		repo.bus.publish(UserDeleted, "users", userData(removed))
+	}
+	return removed, err
+}
+
+func (repo *userRepo) RestoreUser(ctx context.Context, id int) (models.User, error) {
+
+	restored, err := repo.UserRepo.RestoreUser(ctx, id)
+	if err == nil {
+		repo.bus.publish(UserUpdated, "users", userData(restored))
+	}
+	return restored, err
+}
+
+func (repo *userRepo) UpdateRoles(ctx context.Context, changed models.User, roles []models.Role) (models.User, error) {
+
+	updated, err := repo.UserRepo.UpdateRoles(ctx, changed, roles)
+	if err == nil {
+		repo.bus.publish(UserUpdated, "users", userData(updated))
+	}
+	return updated, err
+}
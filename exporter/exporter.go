@@ -0,0 +1,194 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+	"strings"
+	"testApplication/models"
+	"time"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+	XLSX   = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("format must be one of csv, ndjson, xlsx")
+
+var contentTypes = map[string]string{
+	CSV:    "text/csv; charset=utf-8",
+	NDJSON: "application/x-ndjson",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ContentType is the media type of an export format, empty for unknown formats.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Writer encodes clients one at a time, Close writes whatever the format still buffers.
+type Writer interface {
+	Write(client models.Client) error
+	Close() error
+}
+
+// New creates a writer for the format, the tabular formats get a column per custom field definition.
+func New(format string, w io.Writer, definitions []models.CustomField) (Writer, error) {
+
+	switch format {
+	case CSV:
+		return newCsvWriter(w, definitions)
+	case NDJSON:
+		buffered := bufio.NewWriter(w)
+		return ndjsonWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case XLSX:
+		return newXlsxWriter(w, definitions)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// header names the columns the way the importer maps them, so an export can be imported back.
+func header(definitions []models.CustomField) []string {
+
+	columns := []string{"id", "name", "email", "phone", "address", "taxId", "status", "tags", "createdAt", "updatedAt", "deletedAt"}
+	for _, definition := range definitions {
+		columns = append(columns, "customFields."+definition.Name)
+	}
+	return columns
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func record(client models.Client, definitions []models.CustomField) []string {
+
+	row := []string{
+		strconv.Itoa(client.Id),
+		client.Name,
+		client.Email,
+		client.Phone,
+		client.Address,
+		client.TaxId,
+		client.Status,
+		strings.Join(client.Tags, ";"),
+		formatTime(&client.CreatedAt),
+		formatTime(&client.UpdatedAt),
+		formatTime(client.DeletedAt),
+	}
+	for _, definition := range definitions {
+		row = append(row, formatValue(client.CustomFields[definition.Name]))
+	}
+	return row
+}
+
+type csvWriter struct {
+	writer      *csv.Writer
+	definitions []models.CustomField
+}
+
+func newCsvWriter(w io.Writer, definitions []models.CustomField) (Writer, error) {
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header(definitions))
+	return csvWriter{writer: writer, definitions: definitions}, err
+}
+
+func (w csvWriter) Write(client models.Client) error {
+	return w.writer.Write(record(client, w.definitions))
+}
+
+func (w csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w ndjsonWriter) Write(client models.Client) error {
+	return w.encoder.Encode(client)
+}
+
+func (w ndjsonWriter) Close() error {
+	return w.buffered.Flush()
+}
+
+// xlsxWriter spools rows to a temporary file through excelize, a workbook is a zip archive
+// that can only be sent once it is complete.
+type xlsxWriter struct {
+	w           io.Writer
+	file        *excelize.File
+	stream      *excelize.StreamWriter
+	definitions []models.CustomField
+	row         *int
+}
+
+func newXlsxWriter(w io.Writer, definitions []models.CustomField) (Writer, error) {
+
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writer := xlsxWriter{w: w, file: file, stream: stream, definitions: definitions, row: new(int)}
+	err = writer.writeRow(header(definitions))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w xlsxWriter) writeRow(values []string) error {
+
+	*w.row++
+	cell, err := excelize.CoordinatesToCellName(1, *w.row)
+	if err != nil {
+		return err
+	}
+
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w xlsxWriter) Write(client models.Client) error {
+	return w.writeRow(record(client, w.definitions))
+}
+
+func (w xlsxWriter) Close() error {
+	defer w.file.Close()
+
+	err := w.stream.Flush()
+	if err != nil {
+		return err
+	}
+	return w.file.Write(w.w)
+}
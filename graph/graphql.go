@@ -2,23 +2,150 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
 	"net/http"
+	"testApplication/apperrors"
+	"testApplication/dto"
+	"testApplication/events"
 	"testApplication/interfaces"
 	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/redis"
+	"testApplication/search"
+	"testApplication/stream"
+	"testApplication/validation"
 )
 
 type graph struct {
-	repo   interfaces.ClientRepo
-	schema graphql.Schema
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
+	contactsRepo     interfaces.ContactRepo
+	activitiesRepo   interfaces.ActivityRepo
+	userRepo         interfaces.UserRepo
+	hub              *stream.Hub
+	redisConn        *redis.Connection
+	schema           graphql.Schema
 }
 
-func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
+func NewGraph(
+	repo interfaces.ClientRepo,
+	customFieldsRepo interfaces.CustomFieldRepo,
+	contactsRepo interfaces.ContactRepo,
+	activitiesRepo interfaces.ActivityRepo,
+	userRepo interfaces.UserRepo,
+	hub *stream.Hub,
+	redisConn *redis.Connection,
+) (*graph, error) {
 	graph := graph{
-		repo: repo,
+		repo:             repo,
+		customFieldsRepo: customFieldsRepo,
+		contactsRepo:     contactsRepo,
+		activitiesRepo:   activitiesRepo,
+		userRepo:         userRepo,
+		hub:              hub,
+		redisConn:        redisConn,
 	}
+	var clientStatusValues = graphql.EnumValueConfigMap{}
+	for _, status := range models.ClientStatuses {
+		clientStatusValues[status] = &graphql.EnumValueConfig{Value: status}
+	}
+	var clientStatusType = graphql.NewEnum(graphql.EnumConfig{
+		Name:   "ClientStatus",
+		Values: clientStatusValues,
+	})
+
+	var clientArgs = graphql.FieldConfigArgument{
+		"name": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"email": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"phone": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"address": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"taxId": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"status": &graphql.ArgumentConfig{
+			Type: clientStatusType,
+		},
+		"tags": &graphql.ArgumentConfig{
+			Type: graphql.NewList(graphql.String),
+		},
+		"customFields": &graphql.ArgumentConfig{
+			Type: jsonType,
+		},
+	}
+
+	var contactType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Contact",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"name": &graphql.Field{
+				Type: graphql.String,
+			},
+			"email": &graphql.Field{
+				Type: graphql.String,
+			},
+			"phone": &graphql.Field{
+				Type: graphql.String,
+			},
+			"position": &graphql.Field{
+				Type: graphql.String,
+			},
+			"primary": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+		},
+	})
+
+	var activityType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Activity",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"authorId": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"kind": &graphql.Field{
+				Type: graphql.String,
+			},
+			"body": &graphql.Field{
+				Type: graphql.String,
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"updatedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+		},
+	})
+
+	var activityPageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ActivityPage",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type: graphql.NewList(activityType),
+			},
+			"nextCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+
 	var clientType = graphql.NewObject(graphql.ObjectConfig{
 		Name: "Client",
 		Fields: graphql.Fields{
@@ -28,6 +155,120 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 			"name": &graphql.Field{
 				Type: graphql.String,
 			},
+			"email": &graphql.Field{
+				Type: graphql.String,
+			},
+			"phone": &graphql.Field{
+				Type: graphql.String,
+			},
+			"address": &graphql.Field{
+				Type: graphql.String,
+			},
+			"taxId": &graphql.Field{
+				Type: graphql.String,
+			},
+			"status": &graphql.Field{
+				Type: clientStatusType,
+			},
+			"tags": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"updatedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"customFields": &graphql.Field{
+				Type: jsonType,
+			},
+			"version": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Changes on every write, pass it back to update or delete to detect conflicts",
+			},
+			"contacts": &graphql.Field{
+				Type:        graphql.NewList(contactType),
+				Description: "People we talk to at the client",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					client, ok := p.Source.(models.Client)
+					if !ok {
+						return nil, nil
+					}
+					if err := graph.authorize(p.Context, "contacts", "read"); err != nil {
+						return nil, err
+					}
+					return graph.contactsRepo.GetContacts(p.Context, client.Id)
+				},
+			},
+			"activities": &graphql.Field{
+				Type:        activityPageType,
+				Description: "Timeline of calls, meetings and comments, newest first",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
+					"after": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					client, ok := p.Source.(models.Client)
+					if !ok {
+						return nil, nil
+					}
+
+					if err := graph.authorize(p.Context, "activities", "read"); err != nil {
+						return nil, err
+					}
+
+					limit, _ := p.Args["limit"].(int)
+					limit = pagination.Limit(limit)
+
+					var after models.ActivityCursor
+					if cursor, _ := p.Args["after"].(string); cursor != "" {
+						if err := pagination.DecodeCursor(cursor, &after); err != nil {
+							return nil, err
+						}
+					}
+
+					activities, err := graph.activitiesRepo.GetActivities(p.Context, client.Id, after, limit+1)
+					if err != nil {
+						return nil, err
+					}
+					return pagination.ActivityPage(activities, limit)
+				},
+			},
+		},
+	})
+
+	var clientPageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ClientPage",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type: graphql.NewList(clientType),
+			},
+			"nextCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+			"totalCount": &graphql.Field{
+				Type: graphql.Int,
+			},
+		},
+	})
+
+	var clientSearchResultType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ClientSearchResult",
+		Fields: graphql.Fields{
+			"client": &graphql.Field{
+				Type: clientType,
+			},
+			"rank": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"highlights": &graphql.Field{
+				Type:        jsonType,
+				Description: "Matched fields with the matching words wrapped in <mark> tags",
+			},
 		},
 	})
 
@@ -49,32 +290,64 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 					}
 					id := p.Args["id"].(int)
 
-					return graph.repo.GetClientById(context.TODO(), id)
+					return graph.repo.GetClientById(context.TODO(), id, false)
 				},
 			},
 			"clients": &graphql.Field{
-				Type:        graphql.NewList(clientType),
-				Description: "Get clients",
+				Type:        clientPageType,
+				Description: "Get a page of clients, pass nextCursor as after to get the next one",
 				Args: graphql.FieldConfigArgument{
 					"limit": &graphql.ArgumentConfig{
 						Type: graphql.Int,
 					},
-					"offset": &graphql.ArgumentConfig{
-						Type: graphql.Int,
+					"after": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+					"filter": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Comma separated conditions, e.g. name~acme,status=active,customFields.region=eu",
+					},
+					"sort": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Comma separated fields, a leading - sorts descending, e.g. -createdAt,name",
 					},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 
-					offset := 0
-					if p.Args["offset"] != nil {
-						offset, _ = p.Args["offset"].(int)
+					definitions, err := graph.customFieldsRepo.GetCustomFields(context.TODO())
+					if err != nil {
+						return nil, err
 					}
-					limit := 0
-					if p.Args["limit"] != nil {
-						limit, _ = p.Args["limit"].(int)
+
+					request := pagination.ClientsRequest{WithTotal: selects(p, "totalCount")}
+					request.Limit, _ = p.Args["limit"].(int)
+					request.After, _ = p.Args["after"].(string)
+					request.Filter, _ = p.Args["filter"].(string)
+					request.Sort, _ = p.Args["sort"].(string)
+
+					return pagination.Clients(context.TODO(), graph.repo, validation.ClientSchema(definitions), request)
+				},
+			},
+			"searchClients": &graphql.Field{
+				Type:        graphql.NewList(clientSearchResultType),
+				Description: "Search clients by partial or misspelled words, best matches first",
+				Args: graphql.FieldConfigArgument{
+					"q": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"limit": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+
+					terms := search.Terms(p.Args["q"].(string))
+					if len(terms) == 0 {
+						return nil, errors.New("q must contain at least one word")
 					}
+					limit, _ := p.Args["limit"].(int)
 
-					return graph.repo.GetClients(context.TODO(), offset, limit)
+					return graph.repo.SearchClients(context.TODO(), terms, pagination.Limit(limit))
 				},
 			},
 		}})
@@ -83,41 +356,55 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 		Name: "Mutation",
 		Fields: graphql.Fields{
 			"create": &graphql.Field{
-				Type: clientType,
-				Args: graphql.FieldConfigArgument{
-					"name": &graphql.ArgumentConfig{
-						Type: graphql.String,
-					},
-				},
+				Type:        clientType,
+				Args:        clientArgs,
 				Description: "Add client",
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					client := models.Client{
-						Name: p.Args["name"].(string),
+					input := clientFromArgs(p.Args, dto.ClientInput{})
+					if err := validation.Struct(input); err != nil {
+						return nil, invalid(err)
+					}
+					client := input.Model()
+					if err := graph.validateClient(client); err != nil {
+						return nil, err
 					}
 					return graph.repo.CreateClient(context.TODO(), client)
 				},
 			},
 			"update": &graphql.Field{
-				Type: graphql.String,
-				Args: graphql.FieldConfigArgument{
+				Type: clientType,
+				Args: withArgs(clientArgs, graphql.FieldConfigArgument{
 					"id": &graphql.ArgumentConfig{
 						Type: graphql.Int,
 					},
-					"name": &graphql.ArgumentConfig{
-						Type: graphql.String,
+					"version": &graphql.ArgumentConfig{
+						Type: graphql.Int,
 					},
-				},
+				}),
 				Description: "Update client by id",
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					client := models.Client{
-						Id:   p.Args["id"].(int),
-						Name: p.Args["name"].(string),
+
+					id, _ := p.Args["id"].(int)
+					current, err := graph.repo.GetClientById(context.TODO(), id, false)
+					if err != nil {
+						return nil, err
 					}
-					err := graph.repo.UpdateClient(context.TODO(), client)
+
+					// the arguments left out keep their stored values
+					input := dto.ClientUpdateInput{Id: id, ClientInput: clientFromArgs(p.Args, dto.ClientInputOf(current))}
+					input.Version, _ = p.Args["version"].(int)
+					if err := validation.Struct(input); err != nil {
+						return nil, invalid(err)
+					}
+					client := input.Model()
+					if err := graph.validateClient(client); err != nil {
+						return nil, err
+					}
+					err = graph.repo.UpdateClient(context.TODO(), client)
 					if err != nil {
 						return nil, err
 					}
-					return "Success", nil
+					return graph.repo.GetClientById(context.TODO(), client.Id, false)
 				},
 			},
 			"delete": &graphql.Field{
@@ -126,6 +413,9 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 					"id": &graphql.ArgumentConfig{
 						Type: graphql.Int,
 					},
+					"version": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
 				},
 				Description: "Delete client by id",
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
@@ -134,8 +424,9 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 						return nil, errors.New("id is empty")
 					}
 					id := p.Args["id"].(int)
+					version, _ := p.Args["version"].(int)
 
-					err := graph.repo.DeleteClient(context.TODO(), id)
+					err := graph.repo.DeleteClient(context.TODO(), id, version)
 					if err != nil {
 						return nil, err
 					}
@@ -145,13 +436,128 @@ func NewGraph(repo interfaces.ClientRepo) (*graph, error) {
 		},
 	})
 
-	var schema, _ = graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	userQueries, userMutations := graph.userFields()
+	for name, field := range userQueries {
+		queryType.AddFieldConfig(name, field)
+	}
+	for name, field := range userMutations {
+		mutationType.AddFieldConfig(name, field)
+	}
+
+	var subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"clientCreated": &graphql.Field{
+				Type:        clientType,
+				Description: "Clients as they are created",
+				Subscribe:   graph.subscribeClients(events.ClientCreated),
+				Resolve:     changedClient,
+			},
+			"clientUpdated": &graphql.Field{
+				Type:        clientType,
+				Description: "Clients as they are changed, restored or merged into",
+				Subscribe:   graph.subscribeClients(events.ClientUpdated),
+				Resolve:     changedClient,
+			},
+			"clientDeleted": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Ids of clients as they are deleted",
+				Subscribe:   graph.subscribeClients(events.ClientDeleted),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					event := p.Source.(events.Event)
+					var deleted struct {
+						Id int `json:"id"`
+					}
+					err := json.Unmarshal(event.Data, &deleted)
+					return deleted.Id, err
+				},
+			},
+		},
+	})
+
+	var schema, _ = graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType, Subscription: subscriptionType})
 
 	graph.schema = schema
 
 	return &graph, nil
 }
 
+func (graph *graph) validateClient(client models.Client) error {
+
+	definitions, err := graph.customFieldsRepo.GetCustomFields(context.TODO())
+	if err != nil {
+		return err
+	}
+	return invalid(validation.Client(client, definitions))
+}
+
+// invalid turns field errors into the validation error REST callers get as well.
+func invalid(err error) error {
+	if fields, ok := err.(validation.FieldErrors); ok {
+		return apperrors.Validation(fields)
+	}
+	return err
+}
+
+// selects tells whether the query asks for the field directly under the resolved one,
+// so expensive fields are computed only on demand.
+func selects(p graphql.ResolveParams, field string) bool {
+	for _, fieldAST := range p.Info.FieldASTs {
+		if fieldAST.SelectionSet == nil {
+			continue
+		}
+		for _, selection := range fieldAST.SelectionSet.Selections {
+			if selected, ok := selection.(*ast.Field); ok && selected.Name.Value == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func withArgs(args graphql.FieldConfigArgument, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	merged := graphql.FieldConfigArgument{}
+	for name, arg := range args {
+		merged[name] = arg
+	}
+	for name, arg := range extra {
+		merged[name] = arg
+	}
+	return merged
+}
+
+// clientFromArgs sets the fields given as arguments on client.
+func clientFromArgs(args map[string]interface{}, client dto.ClientInput) dto.ClientInput {
+
+	for field, value := range map[string]*string{
+		"name":    &client.Name,
+		"email":   &client.Email,
+		"phone":   &client.Phone,
+		"address": &client.Address,
+		"taxId":   &client.TaxId,
+		"status":  &client.Status,
+	} {
+		if arg, ok := args[field].(string); ok {
+			*value = arg
+		}
+	}
+
+	if customFields, ok := args["customFields"].(map[string]interface{}); ok {
+		client.CustomFields = customFields
+	}
+
+	if tags, ok := args["tags"].([]interface{}); ok {
+		client.Tags = []string{}
+		for _, tag := range tags {
+			if tag, ok := tag.(string); ok {
+				client.Tags = append(client.Tags, tag)
+			}
+		}
+	}
+
+	return client
+}
+
 type RequestParams struct {
 	Query     string                 `json:"query"`
 	Operation string                 `json:"operation"`
@@ -166,7 +572,7 @@ func (graph graph) GraphqlHandler(c *gin.Context) {
 	}
 
 	result := graphql.Do(graphql.Params{
-		Context:        c,
+		Context:        withUser(c, c.GetInt("userId")),
 		Schema:         graph.schema,
 		RequestString:  reqObj.Query,
 		VariableValues: reqObj.Variables,
@@ -174,8 +580,18 @@ func (graph graph) GraphqlHandler(c *gin.Context) {
 	})
 
 	if len(result.Errors) > 0 {
-		c.IndentedJSON(http.StatusBadRequest, result.Errors)
+		c.IndentedJSON(errorStatus(result.Errors), result.Errors)
 		return
 	}
 	c.IndentedJSON(http.StatusOK, result)
 }
+
+// errorStatus answers rejected input with 422 like the REST handlers, anything else with 400.
+func errorStatus(errs []gqlerrors.FormattedError) int {
+	for _, err := range errs {
+		if err.Extensions["code"] == apperrors.KindValidation {
+			return http.StatusUnprocessableEntity
+		}
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+)
+
+// jsonType passes arbitrary JSON values through, it carries client custom fields.
+var jsonType = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Arbitrary JSON value",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: parseJSONLiteral,
+})
+
+func parseJSONLiteral(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.IntValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.FloatValue:
+		number, _ := strconv.ParseFloat(value.Value, 64)
+		return number
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(value.Values))
+		for _, item := range value.Values {
+			list = append(list, parseJSONLiteral(item))
+		}
+		return list
+	case *ast.ObjectValue:
+		object := map[string]interface{}{}
+		for _, field := range value.Fields {
+			object[field.Name.Value] = parseJSONLiteral(field.Value)
+		}
+		return object
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"log"
+	"strings"
+	"sync"
+	"testApplication/apperrors"
+	"testApplication/events"
+	"testApplication/models"
+	"testApplication/redis"
+	"testApplication/stream"
+	"time"
+)
+
+type contextKey string
+
+const userIdKey contextKey = "userId"
+
+// withUser passes the authenticated user to the resolvers.
+func withUser(ctx context.Context, userId int) context.Context {
+	return context.WithValue(ctx, userIdKey, userId)
+}
+
+func currentUser(ctx context.Context) (int, bool) {
+	userId, ok := ctx.Value(userIdKey).(int)
+	return userId, ok && userId > 0
+}
+
+// subscribeClients feeds a subscription field with the client events of eventType, the changes of
+// every instance arrive through the hub. A revoked read grant stops the events without a reconnect.
+func (graph *graph) subscribeClients(eventType string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		userId, ok := currentUser(p.Context)
+		if !ok {
+			return nil, apperrors.New(apperrors.KindUnauthorized, "empty token")
+		}
+		granted, err := graph.userRepo.CheckUserGrant(p.Context, userId, "clients", "read")
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, apperrors.Forbidden("not allowed to read clients")
+		}
+
+		subscription, err := graph.hub.Subscribe(p.Context, "")
+		if err != nil {
+			return nil, err
+		}
+		reader := stream.NewReader(graph.userRepo, userId, "clients")
+
+		changes := make(chan interface{})
+		go func() {
+			defer close(changes)
+			defer subscription.Close()
+			for {
+				select {
+				case <-p.Context.Done():
+					return
+				case message, ok := <-subscription.C:
+					if !ok {
+						return
+					}
+					if message.Event.Type != eventType {
+						continue
+					}
+					allowed, err := reader.Allowed(p.Context, message.Event)
+					if err != nil {
+						log.Println(err)
+						return
+					}
+					if !allowed {
+						continue
+					}
+					select {
+					case changes <- message.Event:
+					case <-p.Context.Done():
+						return
+					}
+				}
+			}
+		}()
+		return changes, nil
+	}
+}
+
+// changedClient resolves a created or updated client from its event.
+func changedClient(p graphql.ResolveParams) (interface{}, error) {
+	event := p.Source.(events.Event)
+	var client models.Client
+	err := json.Unmarshal(event.Data, &client)
+	return client, err
+}
+
+// Both WebSocket subprotocols of GraphQL are spoken: graphql-transport-ws of the graphql-ws library and
+// the older graphql-ws of subscriptions-transport-ws, they differ mostly in the names of the messages.
+const (
+	transportWS = "graphql-transport-ws"
+	legacyWS    = "graphql-ws"
+)
+
+var upgrader = websocket.Upgrader{Subprotocols: []string{transportWS, legacyWS}}
+
+// initTimeout is how long a connection may stay without a connection_init.
+const initTimeout = 10 * time.Second
+
+// Close codes of graphql-transport-ws.
+const (
+	closeBadMessage   = 4400
+	closeUnauthorized = 4401
+	closeInitTimeout  = 4408
+	closeDuplicateId  = 4409
+	closeTooManyInits = 4429
+)
+
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConn is one subscription connection. Writes come from every running operation, so they are serialized.
+type wsConn struct {
+	graph    *graph
+	conn     *websocket.Conn
+	legacy   bool
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	userId   int
+	inited   bool
+	running  map[string]context.CancelFunc
+	finished sync.WaitGroup
+}
+
+// SubscriptionHandler serves queries, mutations and subscriptions over a WebSocket. The bearer token
+// comes from the Authorization header of the upgrade or, for browsers that can not set it, from the
+// Authorization or token member of the connection_init payload.
+func (graph *graph) SubscriptionHandler(c *gin.Context) {
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader answered the request already
+		log.Println(err)
+		return
+	}
+	ws := &wsConn{
+		graph:   graph,
+		conn:    conn,
+		legacy:  conn.Subprotocol() == legacyWS,
+		running: map[string]context.CancelFunc{},
+	}
+	ws.serve(c.Request.Context(), c.GetHeader("Authorization"))
+}
+
+func (ws *wsConn) serve(ctx context.Context, authorization string) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		ws.finished.Wait()
+		ws.conn.Close()
+	}()
+
+	if ws.conn.Subprotocol() == "" {
+		ws.close(websocket.CloseProtocolError, "use the graphql-transport-ws or graphql-ws subprotocol")
+		return
+	}
+
+	initTimer := time.AfterFunc(initTimeout, func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		if !ws.inited {
+			ws.close(closeInitTimeout, "connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	for {
+		var message wsMessage
+		err := ws.conn.ReadJSON(&message)
+		if err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok && !strings.Contains(err.Error(), "use of closed network connection") {
+				ws.close(closeBadMessage, "invalid message")
+			}
+			return
+		}
+
+		switch message.Type {
+		case "connection_init":
+			if !ws.init(ctx, authorization, message.Payload) {
+				return
+			}
+		case "ping":
+			ws.write(wsMessage{Type: "pong", Payload: message.Payload})
+		case "pong":
+		case "subscribe", "start":
+			if !ws.start(ctx, message) {
+				return
+			}
+		case "complete", "stop":
+			ws.stop(message.Id)
+		case "connection_terminate":
+			return
+		default:
+			ws.close(closeBadMessage, "unknown message type "+message.Type)
+			return
+		}
+	}
+}
+
+// init authenticates the connection, false means it was closed.
+func (ws *wsConn) init(ctx context.Context, authorization string, payload json.RawMessage) bool {
+
+	ws.mu.Lock()
+	inited := ws.inited
+	ws.inited = true
+	ws.mu.Unlock()
+	if inited {
+		ws.close(closeTooManyInits, "too many initialisation requests")
+		return false
+	}
+
+	var params struct {
+		Authorization string `json:"Authorization"`
+		Token         string `json:"token"`
+	}
+	if len(payload) > 0 {
+		json.Unmarshal(payload, &params)
+	}
+	token := params.Token
+	for _, header := range []string{params.Authorization, authorization} {
+		if token == "" && strings.HasPrefix(header, "Bearer ") {
+			token = strings.TrimPrefix(header, "Bearer ")
+		}
+	}
+
+	userId, err := ws.graph.redisConn.CheckToken(ctx, token)
+	if token == "" || err != nil {
+		if err != nil && err != redis.ErrUnauthorized {
+			log.Println(err)
+		}
+		if ws.legacy {
+			ws.write(wsMessage{Type: "connection_error", Payload: json.RawMessage(`{"message":"invalid or expired token"}`)})
+		}
+		ws.close(closeUnauthorized, "unauthorized")
+		return false
+	}
+
+	ws.mu.Lock()
+	ws.userId = userId
+	ws.mu.Unlock()
+	ws.write(wsMessage{Type: "connection_ack"})
+	if ws.legacy {
+		ws.write(wsMessage{Type: "ka"})
+	}
+	return true
+}
+
+// start runs an operation until it is done or stopped, false means the connection was closed.
+func (ws *wsConn) start(ctx context.Context, message wsMessage) bool {
+
+	ws.mu.Lock()
+	if ws.userId == 0 {
+		ws.mu.Unlock()
+		ws.close(closeUnauthorized, "unauthorized")
+		return false
+	}
+	if _, ok := ws.running[message.Id]; ok || message.Id == "" {
+		ws.mu.Unlock()
+		ws.close(closeDuplicateId, "subscriber for "+message.Id+" already exists")
+		return false
+	}
+	opCtx, cancel := context.WithCancel(withUser(ctx, ws.userId))
+	ws.running[message.Id] = cancel
+	ws.mu.Unlock()
+
+	var request RequestParams
+	err := json.Unmarshal(message.Payload, &request)
+	if err != nil {
+		cancel()
+		ws.close(closeBadMessage, "invalid subscribe payload")
+		return false
+	}
+	if request.Operation == "" {
+		// graphql-ws clients name the operation operationName
+		var named struct {
+			OperationName string `json:"operationName"`
+		}
+		json.Unmarshal(message.Payload, &named)
+		request.Operation = named.OperationName
+	}
+
+	params := graphql.Params{
+		Context:        opCtx,
+		Schema:         ws.graph.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.Operation,
+	}
+
+	ws.finished.Add(1)
+	go func() {
+		defer ws.finished.Done()
+		defer ws.stop(message.Id)
+
+		var results chan *graphql.Result
+		if isSubscription(request.Query, request.Operation) {
+			results = graphql.Subscribe(params)
+		} else {
+			results = make(chan *graphql.Result, 1)
+			results <- graphql.Do(params)
+			close(results)
+		}
+
+		// the results are read to the end, the subscription stops sending once opCtx is done
+		failed := false
+		for result := range results {
+			if opCtx.Err() != nil || failed {
+				continue
+			}
+			failed = !ws.send(message.Id, result)
+		}
+		if opCtx.Err() == nil && !failed {
+			ws.write(wsMessage{Id: message.Id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// send writes a result, one that only holds errors ends the operation with an error message
+// and false is returned.
+func (ws *wsConn) send(id string, result *graphql.Result) bool {
+
+	if result.Data == nil && len(result.Errors) > 0 {
+		var payload interface{} = result.Errors
+		if ws.legacy {
+			payload = result.Errors[0]
+		}
+		encoded, _ := json.Marshal(payload)
+		ws.write(wsMessage{Id: id, Type: "error", Payload: encoded})
+		return false
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		log.Println(err)
+		encoded, _ = json.Marshal(graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+	}
+	messageType := "next"
+	if ws.legacy {
+		messageType = "data"
+	}
+	ws.write(wsMessage{Id: id, Type: messageType, Payload: encoded})
+	return true
+}
+
+func (ws *wsConn) stop(id string) {
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if cancel, ok := ws.running[id]; ok {
+		cancel()
+		delete(ws.running, id)
+	}
+}
+
+func (ws *wsConn) write(message wsMessage) {
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	err := ws.conn.WriteJSON(message)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (ws *wsConn) close(code int, reason string) {
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	ws.conn.Close()
+}
+
+// isSubscription tells whether the operation to run is a subscription, an unparsable document is left
+// to graphql.Do to report.
+func isSubscription(query string, operationName string) bool {
+
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || operation.Name != nil && operation.Name.Value == operationName {
+			return operation.Operation == ast.OperationTypeSubscription
+		}
+	}
+	return false
+}
@@ -0,0 +1,316 @@
+package graph
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/query"
+)
+
+// userFields adds users, roles and grants to the schema. The User type has no password field, so a
+// hash read along with a user can never be selected.
+func (graph *graph) userFields() (queries graphql.Fields, mutations graphql.Fields) {
+
+	var grantType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Grant",
+		Description: "Operations a role may run on a table",
+		Fields: graphql.Fields{
+			"table": &graphql.Field{
+				Type: graphql.String,
+			},
+			"read": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"create": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"update": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"delete": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+		},
+	})
+
+	var roleType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Role",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"name": &graphql.Field{
+				Type: graphql.String,
+			},
+			"grants": &graphql.Field{
+				Type: graphql.NewList(grantType),
+			},
+		},
+	})
+
+	var userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.ID,
+			},
+			"email": &graphql.Field{
+				Type: graphql.String,
+			},
+			"name": &graphql.Field{
+				Type: graphql.String,
+			},
+			"deletedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"roles": &graphql.Field{
+				Type: graphql.NewList(roleType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user, ok := p.Source.(models.User)
+					if !ok {
+						return nil, nil
+					}
+					if user.Roles != nil {
+						return user.Roles, nil
+					}
+					return graph.userRepo.UserRoles(p.Context, user.Id)
+				},
+			},
+		},
+	})
+
+	var roleArgs = graphql.FieldConfigArgument{
+		"userId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"roleId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	}
+
+	queries = graphql.Fields{
+		"me": &graphql.Field{
+			Type:        userType,
+			Description: "The signed in user",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userId, ok := currentUser(p.Context)
+				if !ok {
+					return nil, apperrors.New(apperrors.KindUnauthorized, "empty token")
+				}
+				return graph.userRepo.ById(p.Context, userId, false)
+			},
+		},
+		"users": &graphql.Field{
+			Type: graphql.NewList(userType),
+			Args: graphql.FieldConfigArgument{
+				"offset": &graphql.ArgumentConfig{
+					Type: graphql.Int,
+				},
+				"limit": &graphql.ArgumentConfig{
+					Type: graphql.Int,
+				},
+				"filter": &graphql.ArgumentConfig{
+					Type: graphql.String,
+				},
+				"sort": &graphql.ArgumentConfig{
+					Type: graphql.String,
+				},
+				"includeDeleted": &graphql.ArgumentConfig{
+					Type: graphql.Boolean,
+				},
+			},
+			Description: "List users, needs the read grant on users",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+
+				includeDeleted, _ := p.Args["includeDeleted"].(bool)
+				err := graph.authorize(p.Context, "users", "read")
+				if err == nil && includeDeleted {
+					err = graph.authorize(p.Context, "users", "delete")
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				schema := query.UserSchema()
+				filterArg, _ := p.Args["filter"].(string)
+				filter, err := query.ParseFilter(filterArg, schema)
+				if err != nil {
+					return nil, apperrors.Wrap(apperrors.KindBadRequest, err)
+				}
+				sortArg, _ := p.Args["sort"].(string)
+				sorts, err := query.ParseSort(sortArg, schema)
+				if err != nil {
+					return nil, apperrors.Wrap(apperrors.KindBadRequest, err)
+				}
+				offset, _ := p.Args["offset"].(int)
+				if offset < 0 {
+					offset = 0
+				}
+				limit, _ := p.Args["limit"].(int)
+
+				return graph.userRepo.List(p.Context, models.ListQuery{
+					Offset:         offset,
+					Limit:          pagination.Limit(limit),
+					IncludeDeleted: includeDeleted,
+					Filter:         filter,
+					Sort:           sorts,
+				})
+			},
+		},
+		"user": &graphql.Field{
+			Type: userType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{
+					Type: graphql.NewNonNull(graphql.Int),
+				},
+				"includeDeleted": &graphql.ArgumentConfig{
+					Type: graphql.Boolean,
+				},
+			},
+			Description: "Get a user, anyone but yourself needs the read grant on users",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+
+				id := p.Args["id"].(int)
+				includeDeleted, _ := p.Args["includeDeleted"].(bool)
+				if userId, _ := currentUser(p.Context); userId != id {
+					if err := graph.authorize(p.Context, "users", "read"); err != nil {
+						return nil, err
+					}
+				}
+				if includeDeleted {
+					if err := graph.authorize(p.Context, "users", "delete"); err != nil {
+						return nil, err
+					}
+				}
+				return graph.userRepo.ById(p.Context, id, includeDeleted)
+			},
+		},
+		"roles": &graphql.Field{
+			Type:        graphql.NewList(roleType),
+			Description: "Every role with its grants, needs the read grant on users",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if err := graph.authorize(p.Context, "users", "read"); err != nil {
+					return nil, err
+				}
+				return graph.userRepo.Roles(p.Context)
+			},
+		},
+	}
+
+	mutations = graphql.Fields{
+		"assignRole": &graphql.Field{
+			Type:        userType,
+			Args:        roleArgs,
+			Description: "Give a user a role, admins only",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return graph.changeRoles(p.Context, p.Args["userId"].(int), func(roles []models.Role) []models.Role {
+					return append(roles, models.Role{Id: p.Args["roleId"].(int)})
+				})
+			},
+		},
+		"revokeRole": &graphql.Field{
+			Type:        userType,
+			Args:        roleArgs,
+			Description: "Take a role from a user, admins only",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return graph.changeRoles(p.Context, p.Args["userId"].(int), func(roles []models.Role) []models.Role {
+					var kept []models.Role
+					for _, role := range roles {
+						if role.Id != p.Args["roleId"].(int) {
+							kept = append(kept, role)
+						}
+					}
+					return kept
+				})
+			},
+		},
+		"setRoles": &graphql.Field{
+			Type: userType,
+			Args: graphql.FieldConfigArgument{
+				"userId": &graphql.ArgumentConfig{
+					Type: graphql.NewNonNull(graphql.Int),
+				},
+				"roleIds": &graphql.ArgumentConfig{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+				},
+			},
+			Description: "Replace all roles of a user, admins only",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return graph.changeRoles(p.Context, p.Args["userId"].(int), func([]models.Role) []models.Role {
+					var roles []models.Role
+					for _, id := range p.Args["roleIds"].([]interface{}) {
+						roles = append(roles, models.Role{Id: id.(int)})
+					}
+					return roles
+				})
+			},
+		},
+	}
+
+	return queries, mutations
+}
+
+// authorize checks the grant of the signed in user.
+func (graph *graph) authorize(ctx context.Context, table string, operation string) error {
+
+	userId, ok := currentUser(ctx)
+	if !ok {
+		return apperrors.New(apperrors.KindUnauthorized, "empty token")
+	}
+	granted, err := graph.userRepo.CheckUserGrant(ctx, userId, table, operation)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return apperrors.Forbidden("not allowed to %s %s", operation, table)
+	}
+	return nil
+}
+
+// changeRoles lets an admin change the roles of a user. Admins can not take the admin role from
+// themselves, so there is always someone left to hand it out.
+func (graph *graph) changeRoles(ctx context.Context, userId int, change func([]models.Role) []models.Role) (interface{}, error) {
+
+	adminId, ok := currentUser(ctx)
+	if !ok {
+		return nil, apperrors.New(apperrors.KindUnauthorized, "empty token")
+	}
+	isAdmin, err := graph.userRepo.CheckUserRole(ctx, adminId, models.AdminRole)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, apperrors.Forbidden("only admins may change roles")
+	}
+
+	current, err := graph.userRepo.UserRoles(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	roles := change(current)
+
+	if userId == adminId {
+		allRoles, err := graph.userRepo.Roles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !keepsAdmin(allRoles, roles) {
+			return nil, apperrors.Forbidden("admins can not revoke their own admin role")
+		}
+	}
+
+	return graph.userRepo.UpdateRoles(ctx, models.User{Id: userId}, roles)
+}
+
+func keepsAdmin(allRoles []models.Role, roles []models.Role) bool {
+	for _, role := range roles {
+		for _, known := range allRoles {
+			if known.Id == role.Id && known.Name == models.AdminRole {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testApplication/apperrors"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/validation"
+)
+
+type activityHandler struct {
+	repo     interfaces.ActivityRepo
+	userRepo interfaces.UserRepo
+}
+
+func NewActivityHandler(repo interfaces.ActivityRepo, userRepo interfaces.UserRepo) (*activityHandler, error) {
+
+	activityHandler := activityHandler{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+
+	return &activityHandler, nil
+}
+
+func (handler *activityHandler) GetActivities(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+	limit = pagination.Limit(limit)
+
+	var after models.ActivityCursor
+	if cursor := c.Query("after"); cursor != "" {
+		err := pagination.DecodeCursor(cursor, &after)
+		if err != nil {
+			fail(c, badRequest(err))
+			return
+		}
+	}
+
+	activities, err := handler.repo.GetActivities(c, clientId, after, limit+1)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	page, err := pagination.ActivityPage(activities, limit)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, page)
+}
+
+func (handler *activityHandler) CreateActivity(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	var input dto.ActivityInput
+	if !decode(c, &input) {
+		return
+	}
+	activity := input.Model()
+	activity.ClientId = clientId
+	activity.AuthorId = c.GetInt("userId")
+
+	err := validation.Activity(activity)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	insertedActivity, err := handler.repo.CreateActivity(c, activity)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "activity": insertedActivity})
+}
+
+// authorizedActivity loads the activity from the path and checks that the caller
+// is its author or an admin, it writes the error response itself.
+func (handler *activityHandler) authorizedActivity(c *gin.Context) (models.Activity, bool) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return models.Activity{}, false
+	}
+	id, ok := pathId(c, "activityId")
+	if !ok {
+		return models.Activity{}, false
+	}
+
+	activity, err := handler.repo.GetActivityById(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return activity, false
+	}
+
+	userId := c.GetInt("userId")
+	if activity.AuthorId == userId {
+		return activity, true
+	}
+
+	isAdmin, err := handler.userRepo.CheckUserRole(c, userId, models.AdminRole)
+	if err != nil {
+		fail(c, err)
+		return activity, false
+	}
+	if !isAdmin {
+		fail(c, apperrors.Forbidden("only the author or an admin can change an activity"))
+		return activity, false
+	}
+
+	return activity, true
+}
+
+func (handler *activityHandler) UpdateActivity(c *gin.Context) {
+
+	var changes dto.ActivityInput
+	if !decode(c, &changes) {
+		return
+	}
+
+	activity, ok := handler.authorizedActivity(c)
+	if !ok {
+		return
+	}
+	activity.Kind = changes.Kind
+	activity.Body = changes.Body
+
+	err := validation.Activity(activity)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	updatedActivity, err := handler.repo.UpdateActivity(c, activity)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "activity": updatedActivity})
+}
+
+func (handler *activityHandler) DeleteActivity(c *gin.Context) {
+
+	activity, ok := handler.authorizedActivity(c)
+	if !ok {
+		return
+	}
+
+	err := handler.repo.DeleteActivity(c, activity.ClientId, activity.Id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"hash"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/utils"
+)
+
+type attachmentHandler struct {
+	repo         interfaces.AttachmentRepo
+	store        interfaces.BlobStore
+	maxSize      int64
+	allowedTypes []string
+}
+
+func NewAttachmentHandler(repo interfaces.AttachmentRepo, store interfaces.BlobStore) (*attachmentHandler, error) {
+
+	attachmentHandler := attachmentHandler{
+		repo:         repo,
+		store:        store,
+		maxSize:      utils.Conf.GetInt64("attachments.maxSize"),
+		allowedTypes: utils.Conf.GetStringSlice("attachments.allowedTypes"),
+	}
+	if attachmentHandler.maxSize <= 0 {
+		return nil, errors.New("attachments.maxSize must be positive")
+	}
+
+	return &attachmentHandler, nil
+}
+
+func (handler *attachmentHandler) GetAttachments(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	attachments, err := handler.repo.GetAttachments(c, clientId)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, attachments)
+}
+
+// sniffContentType detects the type from the content itself, the header sent by the client is not trusted.
+func sniffContentType(file multipart.File) (string, error) {
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	return strings.TrimSpace(strings.Split(contentType, ";")[0]), nil
+}
+
+func (handler *attachmentHandler) allowed(contentType string) bool {
+	for _, allowedType := range handler.allowedTypes {
+		if allowedType == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func newStorageKey(clientId int) (string, error) {
+
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("clients/%d/%s", clientId, hex.EncodeToString(random)), nil
+}
+
+func (handler *attachmentHandler) UploadAttachment(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	// a little headroom for the multipart envelope around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, handler.maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			fail(c, apperrors.New(apperrors.KindTooLarge, "file exceeds %d bytes", handler.maxSize))
+			return
+		}
+		fail(c, badRequest(err))
+		return
+	}
+	if fileHeader.Size > handler.maxSize {
+		fail(c, apperrors.New(apperrors.KindTooLarge, "file exceeds %d bytes", handler.maxSize))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	defer file.Close()
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if !handler.allowed(contentType) {
+		fail(c, apperrors.New(apperrors.KindUnsupportedMediaType, "content type %s is not allowed", contentType))
+		return
+	}
+
+	key, err := newStorageKey(clientId)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	checksum := sha256.New()
+	err = handler.store.Put(c, key, io.TeeReader(file, checksum), fileHeader.Size, contentType)
+	if err != nil {
+		log.Println(err)
+		fail(c, err)
+		return
+	}
+	sum := hex.EncodeToString(checksum.Sum(nil))
+
+	// clients may send the checksum they computed, a mismatch means the upload got corrupted
+	if expected := c.PostForm("checksum"); expected != "" && !strings.EqualFold(expected, sum) {
+		handler.removeBlob(c, key)
+		fail(c, apperrors.BadRequest("checksum mismatch"))
+		return
+	}
+
+	attachment, err := handler.repo.CreateAttachment(c, models.Attachment{
+		ClientId:    clientId,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		Checksum:    sum,
+		StorageKey:  key,
+		UploadedBy:  c.GetInt("userId"),
+	})
+	if err != nil {
+		handler.removeBlob(c, key)
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "attachment": attachment})
+}
+
+func (handler *attachmentHandler) removeBlob(c *gin.Context, key string) {
+	err := handler.store.Delete(c, key)
+	if err != nil {
+		log.Printf("removing blob %s failed: %s", key, err)
+	}
+}
+
+// verifyingReader hashes the content while it is streamed and fails at the end when it does not match.
+type verifyingReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hash.Sum(nil)) != r.expected {
+		return n, errors.New("checksum mismatch")
+	}
+	return n, err
+}
+
+func (handler *attachmentHandler) DownloadAttachment(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathId(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	attachment, err := handler.repo.GetAttachmentById(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	content, err := handler.store.Get(c, attachment.StorageKey)
+	if err != nil {
+		if err == interfaces.ErrBlobNotFound {
+			fail(c, apperrors.NotFound("attachment content is missing"))
+			return
+		}
+		fail(c, err)
+		return
+	}
+	defer content.Close()
+
+	checksum, _ := hex.DecodeString(attachment.Checksum)
+	c.Header("Digest", "sha-256="+base64.StdEncoding.EncodeToString(checksum))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.FileName))
+
+	reader := &verifyingReader{reader: content, hash: sha256.New(), expected: attachment.Checksum}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, nil)
+	if err := c.Errors.Last(); err != nil {
+		// headers are already sent, the Digest header lets the receiver notice the corruption
+		log.Printf("sending attachment %d of client %d failed: %s", id, clientId, err)
+	}
+}
+
+func (handler *attachmentHandler) DeleteAttachment(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathId(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	attachment, err := handler.repo.GetAttachmentById(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	err = handler.repo.DeleteAttachment(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	handler.removeBlob(c, attachment.StorageKey)
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/validation"
+)
+
+// maxBatchOperations bounds the size of one batch request.
+const maxBatchOperations = 500
+
+const (
+	batchAtomic     = "atomic"
+	batchBestEffort = "bestEffort"
+)
+
+type batchRequest struct {
+	Mode       string                   `json:"mode"`
+	Operations []models.ClientOperation `json:"operations"`
+}
+
+type batchHandler struct {
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
+	userRepo         interfaces.UserRepo
+}
+
+func NewBatchHandler(repo interfaces.ClientRepo, customFieldsRepo interfaces.CustomFieldRepo, userRepo interfaces.UserRepo) (*batchHandler, error) {
+
+	batchHandler := batchHandler{
+		repo:             repo,
+		customFieldsRepo: customFieldsRepo,
+		userRepo:         userRepo,
+	}
+
+	return &batchHandler, nil
+}
+
+// check rejects operations that can not run: unknown, not granted to the caller or invalid.
+func (handler *batchHandler) check(c *gin.Context, operations []models.ClientOperation) ([]*models.ClientOperationResult, error) {
+
+	definitions, err := handler.customFieldsRepo.GetCustomFields(c)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, op := range models.ClientOps {
+		granted[op], err = handler.userRepo.CheckUserGrant(c, c.GetInt("userId"), "clients", op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rejected := make([]*models.ClientOperationResult, len(operations))
+	for i, operation := range operations {
+
+		result := &models.ClientOperationResult{Index: i, Op: operation.Op, Id: operation.Id}
+		switch {
+		case !contains(models.ClientOps, operation.Op):
+			result.Status, result.Error = http.StatusBadRequest, "op must be one of create, update, delete"
+		case !granted[operation.Op]:
+			result.Status, result.Error = http.StatusForbidden, "not allowed to "+operation.Op+" clients"
+		case operation.Op != models.ClientOpCreate && operation.Id <= 0:
+			result.Status, result.Error = http.StatusBadRequest, "id is required"
+		case operation.Op != models.ClientOpDelete:
+			if err := validation.Client(operation.Client.WithDefaults(), definitions); err != nil {
+				result.Status, result.Error, result.Errors = http.StatusBadRequest, "validation failed", err
+			}
+		}
+		if result.Status != 0 {
+			rejected[i] = result
+		}
+	}
+	return rejected, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func operationStatus(result models.ClientOperationResult) int {
+	switch {
+	case result.Error == interfaces.ErrVersionConflict.Error():
+		return http.StatusPreconditionFailed
+	case result.Error != "":
+		return http.StatusBadRequest
+	case result.Op == models.ClientOpCreate:
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+// Batch runs a list of create, update and delete operations. In the default atomic mode nothing is
+// applied unless every operation succeeds, in bestEffort mode each operation stands on its own.
+func (handler *batchHandler) Batch(c *gin.Context) {
+
+	var request batchRequest
+	if !decode(c, &request) {
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = batchAtomic
+	}
+	if request.Mode != batchAtomic && request.Mode != batchBestEffort {
+		fail(c, apperrors.BadRequest("mode must be atomic or bestEffort"))
+		return
+	}
+	if len(request.Operations) == 0 || len(request.Operations) > maxBatchOperations {
+		fail(c, apperrors.BadRequest("a batch takes 1 to %d operations", maxBatchOperations))
+		return
+	}
+	for i := range request.Operations {
+		if request.Operations[i].Op != models.ClientOpDelete {
+			request.Operations[i].Client = request.Operations[i].Client.WithDefaults()
+		}
+	}
+
+	rejected, err := handler.check(c, request.Operations)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	results := make([]models.ClientOperationResult, len(request.Operations))
+	var runnable []models.ClientOperation
+	var runnableIndexes []int
+	for i, operation := range request.Operations {
+		if rejected[i] != nil {
+			results[i] = *rejected[i]
+			continue
+		}
+		runnable = append(runnable, operation)
+		runnableIndexes = append(runnableIndexes, i)
+	}
+
+	atomic := request.Mode == batchAtomic
+	if atomic && len(runnable) < len(request.Operations) {
+		for _, i := range runnableIndexes {
+			results[i] = models.ClientOperationResult{Index: i, Op: request.Operations[i].Op, Id: request.Operations[i].Id, Status: http.StatusFailedDependency, Error: "not run, other operations were rejected"}
+		}
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": "batch rejected", "results": results})
+		return
+	}
+
+	ranResults, err := handler.repo.BatchClients(c, runnable, atomic)
+	for j, result := range ranResults {
+		i := runnableIndexes[j]
+		result.Index = i
+		result.Status = operationStatus(result)
+		results[i] = result
+	}
+
+	if atomic && err != nil {
+		// everything was rolled back, only the failed operation keeps its own error
+		for j, i := range runnableIndexes {
+			if j >= len(ranResults) || ranResults[j].Error == "" {
+				results[i] = models.ClientOperationResult{Index: i, Op: request.Operations[i].Op, Id: request.Operations[i].Id, Status: http.StatusFailedDependency, Error: "rolled back"}
+			}
+		}
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": "batch rolled back", "results": results})
+		return
+	}
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"status": "Error", "message": err.Error(), "results": results})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "results": results})
+}
@@ -1,47 +1,246 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/dto"
+	"testApplication/duplicates"
 	"testApplication/interfaces"
 	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/query"
+	"testApplication/search"
+	"testApplication/validation"
 )
 
 type clientHandler struct {
-	repo interfaces.ClientRepo
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
 }
 
-func NewClientHandler(repo interfaces.ClientRepo) (*clientHandler, error) {
+func NewClientHandler(repo interfaces.ClientRepo, customFieldsRepo interfaces.CustomFieldRepo) (*clientHandler, error) {
 
 	clientHandler := clientHandler{
-		repo: repo,
+		repo:             repo,
+		customFieldsRepo: customFieldsRepo,
 	}
 
 	return &clientHandler, nil
 }
 
+func (handler *clientHandler) validClient(c *gin.Context, client models.Client) bool {
+
+	definitions, err := handler.customFieldsRepo.GetCustomFields(c)
+	if err != nil {
+		fail(c, err)
+		return false
+	}
+
+	err = validation.Client(client, definitions)
+	if err != nil {
+		fail(c, invalid(err))
+		return false
+	}
+	return true
+}
+
 func (handler *clientHandler) GetClients(c *gin.Context) {
 
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+	withTotal, ok := queryBool(c, "totalCount")
+	if !ok {
+		return
+	}
+
+	definitions, err := handler.customFieldsRepo.GetCustomFields(c)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	page, err := pagination.Clients(c, handler.repo, validation.ClientSchema(definitions), pagination.ClientsRequest{
+		Filter:         c.Query("filter"),
+		Sort:           c.Query("sort"),
+		After:          c.Query("after"),
+		Limit:          limit,
+		IncludeDeleted: c.GetBool("includeDeleted"),
+		WithTotal:      withTotal,
+	})
+	if err != nil {
+		var queryErr *query.Error
+		if errors.As(err, &queryErr) || err == pagination.ErrInvalidCursor {
+			fail(c, badRequest(err))
+			return
+		}
+		fail(c, err)
+		return
+	}
+
+	setLinkHeader(c, page.NextCursor)
+	c.IndentedJSON(http.StatusOK, page)
+}
+
+// setLinkHeader advertises the first and the next page as RFC 8288 links, next to the successor-version
+// link of a deprecated version.
+func setLinkHeader(c *gin.Context, nextCursor string) {
+
+	pageUrl := func(after string) string {
+		pageQuery := c.Request.URL.Query()
+		pageQuery.Del("after")
+		if after != "" {
+			pageQuery.Set("after", after)
+		}
+		return (&url.URL{Path: c.Request.URL.Path, RawQuery: pageQuery.Encode()}).String()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageUrl(""))}
+	if nextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageUrl(nextCursor)))
+	}
+	c.Writer.Header().Add("Link", strings.Join(links, ", "))
+}
+
+func (handler *clientHandler) SearchClients(c *gin.Context) {
+
+	terms := search.Terms(c.Query("q"))
+	if len(terms) == 0 {
+		fail(c, apperrors.BadRequest("q must contain at least one word"))
+		return
+	}
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+
+	results, err := handler.repo.SearchClients(c, terms, pagination.Limit(limit))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"items": results})
+}
+
+// duplicatesScanLimit bounds the clients one GET /clients/duplicates request checks.
+const duplicatesScanLimit = 1000
+
+type duplicatesCursor struct {
+	After int `json:"after"`
+}
+
+func (handler *clientHandler) GetDuplicates(c *gin.Context) {
+
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+
+	var position duplicatesCursor
+	if cursor := c.Query("after"); cursor != "" {
+		err := pagination.DecodeCursor(cursor, &position)
+		if err != nil {
+			fail(c, badRequest(err))
+			return
+		}
+	}
+
+	groups, after, err := duplicates.Scan(c, handler.repo, position.After, pagination.Limit(limit), duplicatesScanLimit)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	response := gin.H{"items": groups}
+	nextCursor := ""
+	if after != 0 {
+		nextCursor, err = pagination.EncodeCursor(duplicatesCursor{After: after})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		response["nextCursor"] = nextCursor
+	}
+
+	setLinkHeader(c, nextCursor)
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+func (handler *clientHandler) MergeClients(c *gin.Context) {
+
+	var request dto.MergeInput
+	if !decode(c, &request) {
+		return
+	}
+
+	target, err := handler.repo.GetClientById(c, request.TargetId, false)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	source, err := handler.repo.GetClientById(c, request.SourceId, false)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	merged := duplicates.Merge(target, source)
+	if !handler.validClient(c, merged) {
+		return
+	}
+
+	merge, err := handler.repo.MergeClients(c, merged, source.Id, c.GetInt("userId"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	client, err := handler.repo.GetClientById(c, target.Id, false)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "client": client, "merge": merge})
+}
 
-	clients, err := handler.repo.GetClients(c, offset, limit)
+func (handler *clientHandler) GetMerges(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	merges, err := handler.repo.GetClientMerges(c, id)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err})
+		fail(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, clients)
+	c.IndentedJSON(http.StatusOK, merges)
 }
 
 func (handler *clientHandler) GetClientById(c *gin.Context) {
 
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
 
-	client, err := handler.repo.GetClientById(c, id)
+	client, err := handler.repo.GetClientById(c, id, c.GetBool("includeDeleted"))
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err})
+		fail(c, err)
+		return
+	}
+	if notModified(c, clientETag(client)) {
 		return
 	}
 	c.IndentedJSON(http.StatusOK, client)
@@ -49,52 +248,174 @@ func (handler *clientHandler) GetClientById(c *gin.Context) {
 
 func (handler *clientHandler) CreateClient(c *gin.Context) {
 
-	var client models.Client
+	var input dto.ClientInput
+	if !decode(c, &input) {
+		return
+	}
 
-	err := c.BindJSON(&client)
-	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+	client := input.Model()
+	if !handler.validClient(c, client) {
 		return
 	}
 
 	insertedClient, err := handler.repo.CreateClient(c, client)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+		fail(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "client": insertedClient})
+	response := gin.H{"status": "Success", "client": insertedClient}
+
+	// the client is created anyway, the caller decides whether to merge it
+	matches, err := duplicates.Find(c, handler.repo, insertedClient)
+	if err != nil {
+		log.Printf("duplicate check for client %d failed: %s", insertedClient.Id, err)
+	} else if len(matches) > 0 {
+		response["warning"] = "possible duplicates found"
+		response["duplicates"] = matches
+	}
+
+	c.IndentedJSON(http.StatusOK, response)
 }
 
 func (handler *clientHandler) UpdateClient(c *gin.Context) {
 
-	var client models.Client
+	var input dto.ClientUpdateInput
+	fields, ok := decodeFields(c, &input)
+	if !ok {
+		return
+	}
 
-	err := c.BindJSON(&client)
+	// a missing id is reported by the rules below
+	var current models.Client
+	var err error
+	if input.Id > 0 {
+		current, err = handler.repo.GetClientById(c, input.Id, false)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+	}
+
+	merged := input.Over(current, fields)
+	err = validation.Struct(merged)
 	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+	client := merged.Model()
+	if !handler.validClient(c, client) {
+		return
+	}
 
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+	// If-Match takes precedence over a version sent back in the body
+	version, ok := expectedVersion(c, func() (models.Client, error) { return current, nil })
+	if !ok {
 		return
 	}
+	if version != 0 {
+		client.Version = version
+	}
 
 	err = handler.repo.UpdateClient(c, client)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+		fail(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+	updatedClient, err := handler.repo.GetClientById(c, client.Id, false)
+	if err != nil {
+		c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+		return
+	}
+
+	c.Header("ETag", clientETag(updatedClient))
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "client": updatedClient})
+}
+
+// PatchClient changes the fields named in a merge patch or JSON patch document and leaves the others as they are.
+func (handler *clientHandler) PatchClient(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	current, err := handler.repo.GetClientById(c, id, false)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if _, ok := expectedVersion(c, func() (models.Client, error) { return current, nil }); !ok {
+		return
+	}
+
+	var client models.Client
+	fields, ok := readPatch(c, current, &client, models.ClientPatchFields)
+	if !ok {
+		return
+	}
+
+	client = client.WithDefaults()
+	if !handler.validClient(c, client) {
+		return
+	}
+
+	// the patch was applied to the version read above, a concurrent change in between is a conflict
+	client.Id, client.Version = id, current.Version
+	if len(fields) > 0 {
+		err = handler.repo.PatchClient(c, client, fields)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+	}
+
+	updatedClient, err := handler.repo.GetClientById(c, id, false)
+	if err != nil {
+		c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+		return
+	}
+
+	c.Header("ETag", clientETag(updatedClient))
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "client": updatedClient})
 }
 
 func (handler *clientHandler) DeleteClient(c *gin.Context) {
 
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	version, ok := expectedVersion(c, func() (models.Client, error) {
+		return handler.repo.GetClientById(c, id, false)
+	})
+	if !ok {
+		return
+	}
 
-	err := handler.repo.DeleteClient(c, id)
+	err := handler.repo.DeleteClient(c, id, version)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error"})
+		fail(c, err)
 		return
 	}
 
 	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
 }
+
+func (handler *clientHandler) RestoreClient(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	client, err := handler.repo.RestoreClient(c, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "client": client})
+}
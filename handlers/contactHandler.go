@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/validation"
+)
+
+type contactHandler struct {
+	repo interfaces.ContactRepo
+}
+
+func NewContactHandler(repo interfaces.ContactRepo) (*contactHandler, error) {
+
+	contactHandler := contactHandler{
+		repo: repo,
+	}
+
+	return &contactHandler, nil
+}
+
+func (handler *contactHandler) GetContacts(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	contacts, err := handler.repo.GetContacts(c, clientId)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, contacts)
+}
+
+func (handler *contactHandler) GetContactById(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathId(c, "contactId")
+	if !ok {
+		return
+	}
+
+	contact, err := handler.repo.GetContactById(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, contact)
+}
+
+func (handler *contactHandler) CreateContact(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	var input dto.ContactInput
+	if !decode(c, &input) {
+		return
+	}
+	contact := input.Model()
+	contact.ClientId = clientId
+
+	err := validation.Contact(contact)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	insertedContact, err := handler.repo.CreateContact(c, contact)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "contact": insertedContact})
+}
+
+func (handler *contactHandler) UpdateContact(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathId(c, "contactId")
+	if !ok {
+		return
+	}
+	var input dto.ContactInput
+	if !decode(c, &input) {
+		return
+	}
+	contact := input.Model()
+	contact.ClientId, contact.Id = clientId, id
+
+	err := validation.Contact(contact)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	updatedContact, err := handler.repo.UpdateContact(c, contact)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "contact": updatedContact})
+}
+
+func (handler *contactHandler) DeleteContact(c *gin.Context) {
+
+	clientId, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathId(c, "contactId")
+	if !ok {
+		return
+	}
+
+	err := handler.repo.DeleteContact(c, clientId, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/validation"
+)
+
+type customFieldHandler struct {
+	repo interfaces.CustomFieldRepo
+}
+
+func NewCustomFieldHandler(repo interfaces.CustomFieldRepo) (*customFieldHandler, error) {
+
+	customFieldHandler := customFieldHandler{
+		repo: repo,
+	}
+
+	return &customFieldHandler, nil
+}
+
+func (handler *customFieldHandler) GetCustomFields(c *gin.Context) {
+
+	fields, err := handler.repo.GetCustomFields(c)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, fields)
+}
+
+func (handler *customFieldHandler) CreateCustomField(c *gin.Context) {
+
+	var input dto.CustomFieldInput
+	if !decode(c, &input) {
+		return
+	}
+
+	field := input.Model()
+	err := validation.CustomField(field)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	insertedField, err := handler.repo.CreateCustomField(c, field)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "customField": insertedField})
+}
+
+func (handler *customFieldHandler) UpdateCustomField(c *gin.Context) {
+
+	var input dto.CustomFieldUpdateInput
+	if !decode(c, &input) {
+		return
+	}
+
+	field := input.Model()
+	err := validation.CustomField(field)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	updatedField, err := handler.repo.UpdateCustomField(c, field)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "customField": updatedField})
+}
+
+func (handler *customFieldHandler) DeleteCustomField(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	err := handler.repo.DeleteCustomField(c, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+}
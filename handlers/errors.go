@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"testApplication/apperrors"
+	"testApplication/validation"
+)
+
+// fail hands err to the error middleware, which answers it as a problem document, and stops the chain.
+func fail(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// badRequest classifies an error caused by a malformed request.
+func badRequest(err error) error {
+	return apperrors.Wrap(apperrors.KindBadRequest, err)
+}
+
+// invalid turns the field errors of the validation package into a validation error.
+func invalid(err error) error {
+	if fields, ok := err.(validation.FieldErrors); ok {
+		return apperrors.Validation(fields)
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"testApplication/utils"
+)
+
+func clientETag(client models.Client) string {
+	return fmt.Sprintf(`"%d"`, client.Version)
+}
+
+// etagListMatches evaluates an If-Match or If-None-Match list against the current entity tag,
+// weak tags only match when weak comparison is allowed.
+func etagListMatches(header string, etag string, weak bool) bool {
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers 304 when If-None-Match already names the current version.
+func notModified(c *gin.Context, etag string) bool {
+
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && etagListMatches(header, etag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// expectedVersion turns the If-Match precondition of a change into the version the repository has to
+// find, 0 when the request is unconditional. It answers 412 when the client already changed and 428
+// when concurrency.requireIfMatch is set and the header is missing; false means the request is done.
+func expectedVersion(c *gin.Context, current func() (models.Client, error)) (int, bool) {
+
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if utils.Conf.GetBool("concurrency.requireIfMatch") {
+			fail(c, apperrors.New(apperrors.KindPreconditionRequired, "If-Match header is required"))
+			return 0, false
+		}
+		return 0, true
+	}
+
+	client, err := current()
+	if err != nil {
+		fail(c, err)
+		return 0, false
+	}
+	etag := clientETag(client)
+	if !etagListMatches(header, etag, false) {
+		c.Header("ETag", etag)
+		fail(c, apperrors.New(apperrors.KindPreconditionFailed, "the client was changed by someone else"))
+		return 0, false
+	}
+
+	return client.Version, true
+}
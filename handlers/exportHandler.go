@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"testApplication/exporter"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/query"
+	"testApplication/validation"
+	"time"
+)
+
+// exportFlushRows is how many rows are written before they are pushed to the caller.
+const exportFlushRows = 500
+
+type exportHandler struct {
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
+}
+
+func NewExportHandler(repo interfaces.ClientRepo, customFieldsRepo interfaces.CustomFieldRepo) (*exportHandler, error) {
+
+	exportHandler := exportHandler{
+		repo:             repo,
+		customFieldsRepo: customFieldsRepo,
+	}
+
+	return &exportHandler, nil
+}
+
+// ExportClients streams every client matching ?filter= in ?sort= order as ?format=csv|ndjson|xlsx,
+// csv being the default. A failure once rows were sent can only cut the file short.
+func (handler *exportHandler) ExportClients(c *gin.Context) {
+
+	format := c.DefaultQuery("format", exporter.CSV)
+	contentType := exporter.ContentType(format)
+	if contentType == "" {
+		fail(c, badRequest(exporter.ErrUnsupportedFormat))
+		return
+	}
+
+	definitions, err := handler.customFieldsRepo.GetCustomFields(c)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	schema := validation.ClientSchema(definitions)
+	filter, err := query.ParseFilter(c.Query("filter"), schema)
+	if err != nil {
+		fail(c, badRequest(err))
+		return
+	}
+	sorts, err := query.ParseSort(c.Query("sort"), schema)
+	if err != nil {
+		fail(c, badRequest(err))
+		return
+	}
+
+	writer, err := exporter.New(format, c.Writer, definitions)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="clients-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format))
+	c.Status(http.StatusOK)
+
+	rows := 0
+	err = handler.repo.ExportClients(c, models.ListQuery{
+		IncludeDeleted: c.GetBool("includeDeleted"),
+		Filter:         filter,
+		Sort:           sorts,
+	}, func(client models.Client) error {
+		err := writer.Write(client)
+		if err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 && format != exporter.XLSX {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("export failed after %d rows: %s", rows, err)
+		if c.Writer.Written() {
+			return
+		}
+		// nothing reached the caller yet, the error can still be reported properly
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		var queryErr *query.Error
+		if errors.As(err, &queryErr) {
+			fail(c, badRequest(err))
+			return
+		}
+		fail(c, err)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testApplication/apperrors"
+	"testApplication/importer"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/utils"
+)
+
+type importHandler struct {
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
+	jobs             *importer.Jobs
+	maxSize          int64
+}
+
+func NewImportHandler(repo interfaces.ClientRepo, customFieldsRepo interfaces.CustomFieldRepo, jobStore importer.JobStore) (*importHandler, error) {
+
+	importHandler := importHandler{
+		repo:             repo,
+		customFieldsRepo: customFieldsRepo,
+		jobs:             importer.NewJobs(jobStore),
+		maxSize:          utils.Conf.GetInt64("imports.maxSize"),
+	}
+	if importHandler.maxSize <= 0 {
+		return nil, errors.New("imports.maxSize must be positive")
+	}
+
+	return &importHandler, nil
+}
+
+// ImportClients reads a CSV or XLSX file from the "file" form field. "mapping" maps column headers
+// to client fields as a JSON object, "dryRun" only validates and "async" answers with the job id
+// right away instead of waiting for the import to finish.
+func (handler *importHandler) ImportClients(c *gin.Context) {
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, handler.maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			fail(c, apperrors.New(apperrors.KindTooLarge, "file exceeds %d bytes", handler.maxSize))
+			return
+		}
+		fail(c, badRequest(err))
+		return
+	}
+	if fileHeader.Size > handler.maxSize {
+		fail(c, apperrors.New(apperrors.KindTooLarge, "file exceeds %d bytes", handler.maxSize))
+		return
+	}
+
+	mapping := importer.Mapping{}
+	if rawMapping := c.PostForm("mapping"); rawMapping != "" {
+		err = json.Unmarshal([]byte(rawMapping), &mapping)
+		if err != nil {
+			fail(c, apperrors.BadRequest("mapping must be a JSON object of column to field"))
+			return
+		}
+	}
+
+	definitions, err := handler.customFieldsRepo.GetCustomFields(c)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	err = importer.CheckMapping(mapping, definitions)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.PostForm("dryRun"))
+	async, _ := strconv.ParseBool(c.PostForm("async"))
+
+	// the upload is removed when the request ends, an async job outlives it
+	file, err := copyUpload(fileHeader)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	reader, err := importer.Open(fileHeader.Filename, file)
+	if err != nil {
+		removeTemp(file)
+		if err == importer.ErrUnsupportedFormat {
+			fail(c, apperrors.Wrap(apperrors.KindUnsupportedMediaType, err))
+			return
+		}
+		fail(c, badRequest(err))
+		return
+	}
+
+	job, err := handler.jobs.Start(filepath.Base(fileHeader.Filename), dryRun, c.GetInt("userId"))
+	if err != nil {
+		reader.Close()
+		removeTemp(file)
+		fail(c, err)
+		return
+	}
+
+	run := func(ctx context.Context) {
+		defer removeTemp(file)
+		defer reader.Close()
+
+		err := importer.Run(ctx, handler.jobs, job.Id, reader, mapping, definitions, handler.repo)
+		if err != nil {
+			log.Printf("import %s failed: %s", job.Id, err)
+		}
+		handler.jobs.Finish(job.Id, err)
+	}
+
+	if async {
+		go run(context.Background())
+		c.IndentedJSON(http.StatusAccepted, handler.jobs.Get(job.Id))
+		return
+	}
+
+	run(c)
+	c.IndentedJSON(http.StatusOK, handler.jobs.Get(job.Id))
+}
+
+func copyUpload(fileHeader *multipart.FileHeader) (*os.File, error) {
+
+	upload, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Close()
+
+	file, err := os.CreateTemp("", "client-import-*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(file, upload)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeTemp(file)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func removeTemp(file *os.File) {
+	file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		log.Printf("removing %s failed: %s", file.Name(), err)
+	}
+}
+
+// findJob looks up the job of the jobId parameter. A job of another user is not found either, its
+// report lists their rows. It answers the request itself when it returns false.
+func (handler *importHandler) findJob(c *gin.Context) (models.ImportJob, bool) {
+
+	job, found, err := handler.jobs.Find(c, c.Param("jobId"))
+	if err != nil {
+		fail(c, err)
+		return models.ImportJob{}, false
+	}
+	if !found || job.CreatedBy != c.GetInt("userId") {
+		fail(c, apperrors.NotFound("No import found by id %s", c.Param("jobId")))
+		return models.ImportJob{}, false
+	}
+	return job, true
+}
+
+func (handler *importHandler) GetImport(c *gin.Context) {
+
+	job, found := handler.findJob(c)
+	if !found {
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, job)
+}
+
+// GetImportReport downloads the rejected rows of an import as CSV.
+func (handler *importHandler) GetImportReport(c *gin.Context) {
+
+	job, found := handler.findJob(c)
+	if !found {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-report.csv"`, job.Id))
+	c.Status(http.StatusOK)
+
+	report := csv.NewWriter(c.Writer)
+	report.Write([]string{"row", "field", "message"})
+	for _, rowError := range job.Errors {
+		report.Write([]string{strconv.Itoa(rowError.Row), rowError.Field, rowError.Message})
+	}
+	report.Flush()
+	if err := report.Error(); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"testApplication/apperrors"
+	"testApplication/dto"
+)
+
+// decode reads the request body into input strictly and checks its rules, false means the request was answered.
+func decode(c *gin.Context, input interface{}) bool {
+
+	err := dto.Decode(c.Request.Body, input)
+	if err != nil {
+		fail(c, invalid(err))
+		return false
+	}
+	return true
+}
+
+// decodeFields reads the request body into input strictly and returns the members present in it, so an
+// update can tell an omitted field from an empty one. The rules are left to the caller, who checks them
+// once the input is merged with the stored values.
+func decodeFields(c *gin.Context, input interface{}) ([]string, bool) {
+
+	body, err := c.GetRawData()
+	if err != nil {
+		fail(c, badRequest(err))
+		return nil, false
+	}
+	err = dto.DecodeStrict(bytes.NewReader(body), input)
+	if err != nil {
+		fail(c, invalid(err))
+		return nil, false
+	}
+
+	// the body is a valid object at this point
+	var members map[string]json.RawMessage
+	json.Unmarshal(body, &members)
+	fields := make([]string, 0, len(members))
+	for field := range members {
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// pathId reads a positive integer id from the path, false means the request was answered.
+func pathId(c *gin.Context, name string) (int, bool) {
+
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		fail(c, apperrors.Validation(map[string]string{name: "must be a positive integer"}))
+		return 0, false
+	}
+	return id, true
+}
+
+// queryInt reads a non-negative integer query parameter, fallback when it is absent,
+// false means the request was answered.
+func queryInt(c *gin.Context, name string, fallback int) (int, bool) {
+
+	value, ok := c.GetQuery(name)
+	if !ok || value == "" {
+		return fallback, true
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		fail(c, apperrors.Validation(map[string]string{name: "must be a non-negative integer"}))
+		return 0, false
+	}
+	return number, true
+}
+
+// queryBool reads a true or false query parameter, false when it is absent, ok false means the request was answered.
+func queryBool(c *gin.Context, name string) (value bool, ok bool) {
+
+	switch c.Query(name) {
+	case "", "false":
+		return false, true
+	case "true":
+		return true, true
+	}
+	fail(c, apperrors.Validation(map[string]string{name: "must be true or false"}))
+	return false, false
+}
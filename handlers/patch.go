@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"testApplication/apperrors"
+	"testApplication/patch"
+)
+
+// readPatch applies the merge patch or JSON patch in the request body to current and decodes the outcome
+// into result. It returns the changed fields, false means the request was answered because the patch
+// is malformed or touches fields outside allowed.
+func readPatch(c *gin.Context, current interface{}, result interface{}, allowed []string) ([]string, bool) {
+
+	body, err := c.GetRawData()
+	if err != nil {
+		fail(c, badRequest(err))
+		return nil, false
+	}
+
+	fields, err := patch.Document(c.GetHeader("Content-Type"), current, body, result)
+	if err == patch.ErrUnsupportedMediaType {
+		fail(c, apperrors.Wrap(apperrors.KindUnsupportedMediaType, err))
+		return nil, false
+	}
+	if err != nil {
+		fail(c, badRequest(err))
+		return nil, false
+	}
+
+	for _, field := range fields {
+		if !contains(allowed, field) {
+			fail(c, apperrors.BadRequest("field %s can not be changed", field))
+			return nil, false
+		}
+	}
+	return fields, true
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
+	"testApplication/redis"
+	"testApplication/stream"
+	"time"
+)
+
+// heartbeat keeps idle connections from being closed by proxies on the way.
+const heartbeat = 25 * time.Second
+
+type streamHandler struct {
+	hub      *stream.Hub
+	userRepo interfaces.UserRepo
+	upgrader websocket.Upgrader
+}
+
+func NewStreamHandler(hub *stream.Hub, userRepo interfaces.UserRepo) (*streamHandler, error) {
+
+	streamHandler := streamHandler{
+		hub:      hub,
+		userRepo: userRepo,
+	}
+
+	return &streamHandler, nil
+}
+
+// streamMessage is a change as a connection receives it, the reset type tells a resuming
+// subscriber that changes were missed and it has to reload.
+type streamMessage struct {
+	Id    string      `json:"id,omitempty"`
+	Type  string      `json:"type"`
+	Event interface{} `json:"event,omitempty"`
+}
+
+const resetType = "reset"
+
+// subscribe resumes after the Last-Event-ID header, or the lastEventId query parameter for clients
+// that can not set headers, false means the request was answered.
+func (handler *streamHandler) subscribe(c *gin.Context) (*stream.Subscription, bool) {
+
+	lastEventId := c.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.Query("lastEventId")
+	}
+	if _, _, ok := redis.ParseChangeId(lastEventId); lastEventId != "" && !ok {
+		fail(c, apperrors.Validation(map[string]string{"Last-Event-ID": "must be the id of a received change"}))
+		return nil, false
+	}
+
+	subscription, err := handler.hub.Subscribe(c, lastEventId)
+	if err != nil {
+		fail(c, err)
+		return nil, false
+	}
+	return subscription, true
+}
+
+// ClientEvents streams client changes as Server-Sent Events.
+func (handler *streamHandler) ClientEvents(c *gin.Context) {
+
+	subscription, ok := handler.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+	reader := stream.NewReader(handler.userRepo, c.GetInt("userId"), "clients")
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !subscription.Complete {
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", resetType)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		case message, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			allowed, err := reader.Allowed(ctx, message.Event)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if !allowed {
+				continue
+			}
+			data, err := json.Marshal(message.Event)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", message.Id, message.Event.Type, data)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// ClientSocket sends client changes as JSON messages over a WebSocket, the client sends nothing
+// but control frames.
+func (handler *streamHandler) ClientSocket(c *gin.Context) {
+
+	subscription, ok := handler.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+	reader := stream.NewReader(handler.userRepo, c.GetInt("userId"), "clients")
+
+	conn, err := handler.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader answered the request already
+		log.Println(err)
+		return
+	}
+	defer conn.Close()
+
+	// reading handles pings and the close frame, it ends when the connection does
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if !subscription.Complete {
+		err = conn.WriteJSON(streamMessage{Type: resetType})
+		if err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		case message, ok := <-subscription.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "fell behind, resume from the last id"), time.Now().Add(time.Second))
+				return
+			}
+			var allowed bool
+			allowed, err = reader.Allowed(ctx, message.Event)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if !allowed {
+				continue
+			}
+			err = conn.WriteJSON(streamMessage{Id: message.Id, Type: message.Event.Type, Event: message.Event})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
-	"strconv"
+	"testApplication/dto"
 	"testApplication/interfaces"
 	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/query"
+	"testApplication/validation"
 )
 
 type UserHandler struct {
@@ -23,12 +26,37 @@ func NewUserHandler(repo interfaces.UserRepo) (*UserHandler, error) {
 }
 
 func (handler *UserHandler) List(c *gin.Context) {
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	offset, ok := queryInt(c, "offset", 0)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(c, "limit", 0)
+	if !ok {
+		return
+	}
+	limit = pagination.Limit(limit)
 
-	user, err := handler.Repo.List(c, offset, limit)
+	schema := query.UserSchema()
+	filter, err := query.ParseFilter(c.Query("filter"), schema)
+	if err != nil {
+		fail(c, badRequest(err))
+		return
+	}
+	sort, err := query.ParseSort(c.Query("sort"), schema)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err})
+		fail(c, badRequest(err))
+		return
+	}
+
+	user, err := handler.Repo.List(c, models.ListQuery{
+		Offset:         offset,
+		Limit:          limit,
+		IncludeDeleted: c.GetBool("includeDeleted"),
+		Filter:         filter,
+		Sort:           sort,
+	})
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
@@ -37,11 +65,14 @@ func (handler *UserHandler) List(c *gin.Context) {
 
 func (handler *UserHandler) ById(c *gin.Context) {
 
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
 
-	user, err := handler.Repo.ById(c, id)
+	user, err := handler.Repo.ById(c, id, c.GetBool("includeDeleted"))
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err})
+		fail(c, err)
 		return
 	}
 	c.IndentedJSON(http.StatusOK, user)
@@ -49,19 +80,17 @@ func (handler *UserHandler) ById(c *gin.Context) {
 
 func (handler *UserHandler) CreateUser(c *gin.Context) {
 
-	var user models.User
-
-	err := c.BindJSON(&user)
-	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+	var input dto.UserInput
+	if !decode(c, &input) {
 		return
 	}
 
+	user := input.Model()
 	pass, _ := bcrypt.GenerateFromPassword([]byte(user.Password), 14)
 	user.Password = string(pass)
 	insertedUser, err := handler.Repo.CreateUser(c, user)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+		fail(c, err)
 		return
 	}
 
@@ -69,17 +98,65 @@ func (handler *UserHandler) CreateUser(c *gin.Context) {
 }
 
 func (handler *UserHandler) UpdateUser(c *gin.Context) {
-	var user models.User
+	var input dto.UserUpdateInput
+	if !decode(c, &input) {
+		return
+	}
+
+	user, err := handler.Repo.UpdateUser(c, input.Model())
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "user": user})
+}
+
+// userDocument is the patchable view of a user, the password is write only.
+type userDocument struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+}
 
-	err := c.BindJSON(&user)
+// PatchUser changes the fields named in a merge patch or JSON patch document and leaves the others as they are.
+func (handler *UserHandler) PatchUser(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	current, err := handler.Repo.ById(c, id, false)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+		fail(c, err)
+		return
+	}
+
+	var document userDocument
+	fields, ok := readPatch(c, userDocument{Name: current.Name, Email: current.Email}, &document, models.UserPatchFields)
+	if !ok {
 		return
 	}
 
-	user, err = handler.Repo.UpdateUser(c, user)
+	user := models.User{Id: id, Name: document.Name, Email: document.Email}
+	var password *string
+	if contains(fields, "password") {
+		password = &document.Password
+	}
+	err = validation.User(user, password)
+	if err != nil {
+		fail(c, invalid(err))
+		return
+	}
+	if password != nil {
+		pass, _ := bcrypt.GenerateFromPassword([]byte(document.Password), 14)
+		user.Password = string(pass)
+	}
+
+	user, err = handler.Repo.PatchUser(c, user, fields)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error", "message": err})
+		fail(c, err)
 		return
 	}
 
@@ -88,11 +165,30 @@ func (handler *UserHandler) UpdateUser(c *gin.Context) {
 
 func (handler *UserHandler) DeleteUser(c *gin.Context) {
 
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
 
 	user, err := handler.Repo.DeleteUser(c, id)
 	if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"status": "Error"})
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "user": user})
+}
+
+func (handler *UserHandler) RestoreUser(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := handler.Repo.RestoreUser(c, id)
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
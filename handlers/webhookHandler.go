@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/webhooks"
+)
+
+type webhookHandler struct {
+	repo interfaces.WebhookRepo
+}
+
+func NewWebhookHandler(repo interfaces.WebhookRepo) (*webhookHandler, error) {
+
+	webhookHandler := webhookHandler{
+		repo: repo,
+	}
+
+	return &webhookHandler, nil
+}
+
+func (handler *webhookHandler) GetWebhooks(c *gin.Context) {
+
+	hooks, err := handler.repo.GetWebhooks(c)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	c.IndentedJSON(http.StatusOK, hooks)
+}
+
+func (handler *webhookHandler) GetWebhookById(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := handler.repo.GetWebhookById(c, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	webhook.Secret = ""
+
+	c.IndentedJSON(http.StatusOK, webhook)
+}
+
+// CreateWebhook answers with the secret, it is not shown again afterwards.
+func (handler *webhookHandler) CreateWebhook(c *gin.Context) {
+
+	var input dto.WebhookInput
+	if !decode(c, &input) {
+		return
+	}
+
+	webhook := input.Model()
+	if webhook.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		webhook.Secret = secret
+	}
+	webhook.CreatedBy = c.GetInt("userId")
+
+	insertedWebhook, err := handler.repo.CreateWebhook(c, webhook)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "webhook": insertedWebhook})
+}
+
+func (handler *webhookHandler) UpdateWebhook(c *gin.Context) {
+
+	var input dto.WebhookUpdateInput
+	if !decode(c, &input) {
+		return
+	}
+
+	updatedWebhook, err := handler.repo.UpdateWebhook(c, input.Model())
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	updatedWebhook.Secret = ""
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "webhook": updatedWebhook})
+}
+
+func (handler *webhookHandler) DeleteWebhook(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+
+	err := handler.repo.DeleteWebhook(c, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success"})
+}
+
+// GetDeliveries is the delivery log of a webhook, newest first, status=dead lists the dead letters.
+func (handler *webhookHandler) GetDeliveries(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		fail(c, apperrors.Validation(map[string]string{"status": "must be one of " + strings.Join(models.DeliveryStatuses, ", ")}))
+		return
+	}
+	limit, ok := queryInt(c, "limit", pagination.DefaultLimit)
+	if !ok {
+		return
+	}
+
+	_, err := handler.repo.GetWebhookById(c, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	deliveries, err := handler.repo.GetDeliveries(c, id, status, pagination.Limit(limit))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, deliveries)
+}
+
+// RetryDelivery queues a dead delivery again, it is attempted with the next batch.
+func (handler *webhookHandler) RetryDelivery(c *gin.Context) {
+
+	id, ok := pathId(c, "id")
+	if !ok {
+		return
+	}
+	deliveryId, ok := pathId(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := handler.repo.RetryDelivery(c, id, int64(deliveryId))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"status": "Success", "delivery": delivery})
+}
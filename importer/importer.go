@@ -0,0 +1,228 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/query"
+	"testApplication/validation"
+)
+
+// clientFields are the columns a file can be mapped to, besides customFields.<name>.
+var clientFields = []string{"name", "email", "phone", "address", "taxId", "status", "tags"}
+
+// Mapping maps a column header of the file to a client field.
+type Mapping map[string]string
+
+// CheckMapping rejects mappings to fields that do not exist.
+func CheckMapping(mapping Mapping, definitions []models.CustomField) error {
+
+	errs := validation.FieldErrors{}
+	for column, field := range mapping {
+		if !knownField(field, definitions) {
+			errs["mapping."+column] = fmt.Sprintf("%q is not a client field", field)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func knownField(field string, definitions []models.CustomField) bool {
+
+	if name, found := strings.CutPrefix(field, query.CustomFieldsPrefix); found {
+		_, defined := definitionByName(definitions, name)
+		return defined
+	}
+	for _, clientField := range clientFields {
+		if clientField == field {
+			return true
+		}
+	}
+	return false
+}
+
+func definitionByName(definitions []models.CustomField, name string) (models.CustomField, bool) {
+	for _, definition := range definitions {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+	return models.CustomField{}, false
+}
+
+// columns resolves the header into the field of every column, columns the mapping leaves out
+// are matched by their header being a field name and ignored otherwise.
+func columns(header []string, mapping Mapping, definitions []models.CustomField) []string {
+
+	fields := make([]string, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if field, mapped := mapping[column]; mapped {
+			fields[i] = field
+		} else if knownField(column, definitions) {
+			fields[i] = column
+		}
+	}
+	return fields
+}
+
+// customFieldValue converts a cell into the JSON type the custom field definition expects.
+func customFieldValue(definition models.CustomField, cell string) (interface{}, error) {
+
+	switch definition.Type {
+	case models.CustomFieldNumber:
+		number, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return number, nil
+	case models.CustomFieldBool:
+		boolean, err := strconv.ParseBool(strings.ToLower(cell))
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return boolean, nil
+	}
+	return cell, nil
+}
+
+// client builds a client from a row, cells that can not be converted are reported in errs.
+func client(fields []string, row []string, definitions []models.CustomField, errs validation.FieldErrors) models.Client {
+
+	client := models.Client{Tags: []string{}, CustomFields: map[string]interface{}{}}
+	for i, cell := range row {
+		cell = strings.TrimSpace(cell)
+		if i >= len(fields) || fields[i] == "" || cell == "" {
+			continue
+		}
+
+		switch field := fields[i]; field {
+		case "name":
+			client.Name = cell
+		case "email":
+			client.Email = cell
+		case "phone":
+			client.Phone = cell
+		case "address":
+			client.Address = cell
+		case "taxId":
+			client.TaxId = cell
+		case "status":
+			client.Status = strings.ToLower(cell)
+		case "tags":
+			for _, tag := range strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == ',' }) {
+				client.Tags = append(client.Tags, strings.TrimSpace(tag))
+			}
+		default:
+			name := strings.TrimPrefix(field, query.CustomFieldsPrefix)
+			definition, _ := definitionByName(definitions, name)
+			value, err := customFieldValue(definition, cell)
+			if err != nil {
+				errs[field] = err.Error()
+				continue
+			}
+			client.CustomFields[name] = value
+		}
+	}
+	return client.WithDefaults()
+}
+
+func rowErrors(row int, errs validation.FieldErrors) []models.ImportRowError {
+
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	rowErrors := make([]models.ImportRowError, 0, len(fields))
+	for _, field := range fields {
+		rowErrors = append(rowErrors, models.ImportRowError{Row: row, Field: field, Message: errs[field]})
+	}
+	return rowErrors
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Run validates every row of the file and, unless the job is a dry run, stores the valid
+// rows at once: either all of them are imported or none. Progress is published through jobs.
+func Run(ctx context.Context, jobs *Jobs, jobId string, reader RowReader, mapping Mapping, definitions []models.CustomField, repo interfaces.ClientRepo) error {
+
+	header, err := reader.Next()
+	if err == io.EOF {
+		return errors.New("the file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	fields := columns(header, mapping, definitions)
+
+	var clients []models.Client
+	for rowNumber := 2; ; rowNumber++ {
+
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %w", rowNumber, err)
+		}
+		if isBlank(row) {
+			continue
+		}
+
+		errs := validation.FieldErrors{}
+		newClient := client(fields, row, definitions, errs)
+		if err := validation.Client(newClient, definitions); err != nil {
+			var fieldErrs validation.FieldErrors
+			if !errors.As(err, &fieldErrs) {
+				return err
+			}
+			for field, message := range fieldErrs {
+				if _, reported := errs[field]; !reported {
+					errs[field] = message
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			jobs.update(jobId, func(job *models.ImportJob) {
+				job.ProcessedRows++
+				job.InvalidRows++
+				job.Errors = append(job.Errors, rowErrors(rowNumber, errs)...)
+			})
+			continue
+		}
+		clients = append(clients, newClient)
+		jobs.update(jobId, func(job *models.ImportJob) {
+			job.ProcessedRows++
+			job.ValidRows++
+		})
+	}
+
+	if jobs.Get(jobId).DryRun || len(clients) == 0 {
+		return nil
+	}
+
+	_, err = repo.ImportClients(ctx, clients, func(imported int) {
+		jobs.update(jobId, func(job *models.ImportJob) {
+			job.ImportedRows = imported
+		})
+	})
+	return err
+}
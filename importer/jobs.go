@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"testApplication/models"
+	"time"
+)
+
+// jobRetention is how long a finished job and its report can be fetched.
+const jobRetention = 24 * time.Hour
+
+// saveInterval limits how often the progress of a running job is written to the store.
+const saveInterval = time.Second
+
+// JobStore shares job snapshots between the instances, so any of them can answer for a job.
+type JobStore interface {
+	SaveImportJob(ctx context.Context, job models.ImportJob, ttl time.Duration) error
+	GetImportJob(ctx context.Context, id string) (models.ImportJob, bool, error)
+}
+
+// Jobs keeps the jobs running on this instance in memory and saves their progress to the store.
+type Jobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*models.ImportJob
+	savedAt map[string]time.Time
+	store   JobStore
+}
+
+func NewJobs(store JobStore) *Jobs {
+	return &Jobs{jobs: map[string]*models.ImportJob{}, savedAt: map[string]time.Time{}, store: store}
+}
+
+func newJobId() (string, error) {
+
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Start registers a running job of the user createdBy and forgets the ones finished longer than
+// jobRetention ago.
+func (jobs *Jobs) Start(fileName string, dryRun bool, createdBy int) (models.ImportJob, error) {
+
+	id, err := newJobId()
+	if err != nil {
+		return models.ImportJob{}, err
+	}
+	job := &models.ImportJob{
+		Id:        id,
+		Status:    models.ImportRunning,
+		DryRun:    dryRun,
+		FileName:  fileName,
+		CreatedBy: createdBy,
+		Errors:    []models.ImportRowError{},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	jobs.mu.Lock()
+	for id, finished := range jobs.jobs {
+		if finished.FinishedAt != nil && time.Since(*finished.FinishedAt) > jobRetention {
+			delete(jobs.jobs, id)
+			delete(jobs.savedAt, id)
+		}
+	}
+	jobs.jobs[job.Id] = job
+	jobs.savedAt[job.Id] = time.Now()
+	jobs.mu.Unlock()
+
+	jobs.save(*job)
+	return *job, nil
+}
+
+// Get returns a snapshot of a job of this instance, the zero value when it is unknown.
+func (jobs *Jobs) Get(id string) models.ImportJob {
+
+	jobs.mu.Lock()
+	defer jobs.mu.Unlock()
+
+	job, found := jobs.jobs[id]
+	if !found {
+		return models.ImportJob{}
+	}
+	return snapshot(job)
+}
+
+// Find returns a job of any instance, looking in the store when it does not run on this one.
+func (jobs *Jobs) Find(ctx context.Context, id string) (models.ImportJob, bool, error) {
+
+	if job := jobs.Get(id); job.Id != "" {
+		return job, true, nil
+	}
+	if jobs.store == nil {
+		return models.ImportJob{}, false, nil
+	}
+	return jobs.store.GetImportJob(ctx, id)
+}
+
+func snapshot(job *models.ImportJob) models.ImportJob {
+	copied := *job
+	copied.Errors = append([]models.ImportRowError{}, job.Errors...)
+	return copied
+}
+
+func (jobs *Jobs) update(id string, change func(job *models.ImportJob)) {
+	jobs.apply(id, change, false)
+}
+
+// apply changes the job and saves it when force is set or saveInterval passed since the last save.
+func (jobs *Jobs) apply(id string, change func(job *models.ImportJob), force bool) {
+
+	jobs.mu.Lock()
+	job, found := jobs.jobs[id]
+	if !found {
+		jobs.mu.Unlock()
+		return
+	}
+	change(job)
+	due := force || time.Since(jobs.savedAt[id]) >= saveInterval
+	var saved models.ImportJob
+	if due {
+		jobs.savedAt[id] = time.Now()
+		saved = snapshot(job)
+	}
+	jobs.mu.Unlock()
+
+	if due {
+		jobs.save(saved)
+	}
+}
+
+// save stores the job for other instances, one that is not stored can still be fetched here.
+func (jobs *Jobs) save(job models.ImportJob) {
+
+	if jobs.store == nil {
+		return
+	}
+	err := jobs.store.SaveImportJob(context.Background(), job, jobRetention)
+	if err != nil {
+		log.Printf("saving import %s failed: %s", job.Id, err)
+	}
+}
+
+// Finish marks the job done, or failed when err is not nil.
+func (jobs *Jobs) Finish(id string, err error) {
+	jobs.apply(id, func(job *models.ImportJob) {
+		finishedAt := time.Now().UTC()
+		job.FinishedAt = &finishedAt
+		job.Status = models.ImportDone
+		if err != nil {
+			job.Status = models.ImportFailed
+			job.Error = err.Error()
+			job.ImportedRows = 0
+		}
+	}, true)
+}
@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("only .csv and .xlsx files can be imported")
+
+// RowReader streams the rows of a spreadsheet, Next returns io.EOF after the last one.
+type RowReader interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+type csvReader struct {
+	reader *csv.Reader
+}
+
+func (r csvReader) Next() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r csvReader) Close() error {
+	return nil
+}
+
+// xlsxReader reads the first sheet of a workbook.
+type xlsxReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func (r xlsxReader) Next() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return r.rows.Columns()
+}
+
+func (r xlsxReader) Close() error {
+	if err := r.rows.Close(); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
+
+// Open picks the reader by the file extension.
+func Open(fileName string, content io.Reader) (RowReader, error) {
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		reader := csv.NewReader(content)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return csvReader{reader: reader}, nil
+	case ".xlsx":
+		file, err := excelize.OpenReader(content)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := file.Rows(file.GetSheetName(0))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return xlsxReader{file: file, rows: rows}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
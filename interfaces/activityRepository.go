@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"testApplication/models"
+	"time"
+)
+
+type ActivityRepo interface {
+	// GetActivities lists a client's activities newest first, starting right after the cursor.
+	GetActivities(ctx context.Context, clientId int, after models.ActivityCursor, limit int) ([]models.Activity, error)
+	GetActivityById(ctx context.Context, clientId int, id int) (models.Activity, error)
+	CreateActivity(ctx context.Context, activity models.Activity) (models.Activity, error)
+	UpdateActivity(ctx context.Context, activity models.Activity) (models.Activity, error)
+	// DeleteActivity soft-deletes an activity, PurgeDeletedActivities removes it for good once it is old enough.
+	DeleteActivity(ctx context.Context, clientId int, id int) error
+	PurgeDeletedActivities(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
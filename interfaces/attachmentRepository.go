@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"testApplication/models"
+	"time"
+)
+
+type AttachmentRepo interface {
+	GetAttachments(ctx context.Context, clientId int) ([]models.Attachment, error)
+	GetAttachmentById(ctx context.Context, clientId int, id int) (models.Attachment, error)
+	CreateAttachment(ctx context.Context, attachment models.Attachment) (models.Attachment, error)
+	DeleteAttachment(ctx context.Context, clientId int, id int) error
+
+	// PurgeDeletedAttachments removes metadata of attachments deleted together with their
+	// clients and returns the storage keys whose contents should be removed as well.
+	PurgeDeletedAttachments(ctx context.Context, deletedBefore time.Time) ([]string, error)
+}
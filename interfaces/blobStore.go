@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"testApplication/apperrors"
+)
+
+var ErrBlobNotFound error = apperrors.NotFound("blob not found")
+
+// BlobStore keeps file contents, their metadata lives in the repositories.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
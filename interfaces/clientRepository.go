@@ -2,13 +2,47 @@ package interfaces
 
 import (
 	"context"
+	"testApplication/apperrors"
 	"testApplication/models"
+	"time"
 )
 
+// ErrVersionConflict is returned when a client changed since the version an update or delete expects.
+var ErrVersionConflict error = apperrors.New(apperrors.KindPreconditionFailed, "the client was changed by someone else")
+
 type ClientRepo interface {
-	GetClients(ctx context.Context, offset int, limit int) ([]models.Client, error)
-	GetClientById(ctx context.Context, id int) (models.Client, error)
+	GetClients(ctx context.Context, query models.ListQuery) ([]models.Client, error)
+	// ExportClients streams the clients matching the query to each without loading them all,
+	// an error returned by each stops the export.
+	ExportClients(ctx context.Context, query models.ListQuery, each func(models.Client) error) error
+	// CountClients counts the clients matching the query filter, ignoring offset and limit.
+	CountClients(ctx context.Context, query models.ListQuery) (int64, error)
+	// SearchClients finds the clients matching the search terms by prefix or by trigram similarity, best first.
+	SearchClients(ctx context.Context, terms []string, limit int) ([]models.ClientSearchResult, error)
+	GetClientById(ctx context.Context, id int, includeDeleted bool) (models.Client, error)
 	CreateClient(context.Context, models.Client) (models.Client, error)
 	UpdateClient(context.Context, models.Client) error
-	DeleteClient(ctx context.Context, id int) error
+	// PatchClient writes only the listed fields of client, named as in models.ClientPatchFields,
+	// with the same version check as UpdateClient.
+	PatchClient(ctx context.Context, client models.Client, fields []string) error
+	DeleteClient(ctx context.Context, id int, version int) error
+	RestoreClient(ctx context.Context, id int) (models.Client, error)
+
+	// DuplicateCandidates returns clients sharing the tax id or the email domain or with a similar name,
+	// it only narrows down the clients duplicates.Match has to score.
+	DuplicateCandidates(ctx context.Context, query models.DuplicateQuery, limit int) ([]models.Client, error)
+	// MergeClients saves the merged target, moves the source contacts, activities and attachments
+	// to it, deletes the source and records the merge.
+	MergeClients(ctx context.Context, target models.Client, sourceId int, mergedBy int) (models.ClientMerge, error)
+	GetClientMerges(ctx context.Context, targetId int) ([]models.ClientMerge, error)
+
+	// BatchClients runs the operations in order and reports each of them. Atomic batches run in one
+	// transaction and return the error of the first failed operation after rolling everything back,
+	// otherwise every operation stands on its own and failures are only reported in the results.
+	BatchClients(ctx context.Context, operations []models.ClientOperation, atomic bool) ([]models.ClientOperationResult, error)
+	// ImportClients stores the clients in one transaction and returns them as stored, progress is
+	// called with the number of clients sent so far.
+	ImportClients(ctx context.Context, clients []models.Client, progress func(imported int)) ([]models.Client, error)
+
+	PurgeDeletedClients(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"testApplication/models"
+)
+
+type ContactRepo interface {
+	GetContacts(ctx context.Context, clientId int) ([]models.Contact, error)
+	GetContactById(ctx context.Context, clientId int, id int) (models.Contact, error)
+	CreateContact(ctx context.Context, contact models.Contact) (models.Contact, error)
+	UpdateContact(ctx context.Context, contact models.Contact) (models.Contact, error)
+	DeleteContact(ctx context.Context, clientId int, id int) error
+}
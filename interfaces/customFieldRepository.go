@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"testApplication/models"
+)
+
+type CustomFieldRepo interface {
+	GetCustomFields(ctx context.Context) ([]models.CustomField, error)
+	CreateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error)
+	UpdateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error)
+	DeleteCustomField(ctx context.Context, id int) error
+}
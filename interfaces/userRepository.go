@@ -4,19 +4,30 @@ import (
 	"context"
 	"errors"
 	"testApplication/models"
+	"time"
 )
 
 var ErrNoRows = errors.New("no field found")
 
 type UserRepo interface {
-	List(ctx context.Context, offset int, limit int) ([]models.User, error)
-	ById(ctx context.Context, id int) (models.User, error)
+	List(ctx context.Context, query models.ListQuery) ([]models.User, error)
+	ById(ctx context.Context, id int, includeDeleted bool) (models.User, error)
 	ByEmail(ctx context.Context, email string) (models.User, error)
 	CreateUser(ctx context.Context, newUser models.User) (models.User, error)
 	UpdateUser(ctx context.Context, user models.User) (models.User, error)
+	// PatchUser writes only the listed fields of user, named as in models.UserPatchFields.
+	PatchUser(ctx context.Context, user models.User, fields []string) (models.User, error)
 	DeleteUser(ctx context.Context, id int) (models.User, error)
+	RestoreUser(ctx context.Context, id int) (models.User, error)
 
+	// Roles lists every role with its grants.
+	Roles(ctx context.Context) ([]models.Role, error)
+	UserRoles(ctx context.Context, userId int) ([]models.Role, error)
+	// UpdateRoles replaces the roles of the user, the roles are named by id. The user comes back with its roles.
 	UpdateRoles(ctx context.Context, user models.User, roles []models.Role) (models.User, error)
 
 	CheckUserGrant(ctx context.Context, userId int, table string, operation string) (found bool, err error)
+	CheckUserRole(ctx context.Context, userId int, role string) (found bool, err error)
+
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+	"testApplication/models"
+	"time"
+)
+
+type WebhookRepo interface {
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhookById(ctx context.Context, id int) (models.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	// UpdateWebhook changes the url, events and active flag, the secret stays.
+	UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+
+	// EnqueueDeliveries queues the event for every active webhook subscribed to its type.
+	EnqueueDeliveries(ctx context.Context, eventId string, eventType string, payload []byte) error
+	// ClaimDeliveries returns up to limit pending deliveries that are due and moves their next attempt
+	// lease into the future, so other instances skip them while they are being sent.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordAttempt stores status, attempts, next attempt and the last response of a delivery.
+	RecordAttempt(ctx context.Context, delivery models.WebhookDelivery) error
+	// GetDeliveries lists the deliveries of a webhook newest first, all of them for an empty status.
+	GetDeliveries(ctx context.Context, webhookId int, status string, limit int) ([]models.WebhookDelivery, error)
+	// RetryDelivery queues a dead delivery again with a fresh attempt count.
+	RetryDelivery(ctx context.Context, webhookId int, id int64) (models.WebhookDelivery, error)
+
+	PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error)
+}
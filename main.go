@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"log"
+	"net"
 	"os"
+	"testApplication/blobstores/local"
+	"testApplication/blobstores/s3"
+	"testApplication/events"
 	"testApplication/graph"
 	"testApplication/handlers"
 	"testApplication/interfaces"
 	"testApplication/middleware"
+	"testApplication/openapi"
 	"testApplication/redis"
 	"testApplication/repositories/mongodb"
 	"testApplication/repositories/postgres"
+	"testApplication/retention"
+	"testApplication/rpc"
+	"testApplication/stream"
 	"testApplication/utils"
+	"testApplication/webhooks"
+	"time"
 )
 
 func main() {
@@ -30,42 +42,221 @@ func main() {
 	usingDatabase := utils.Conf.Get("usingDatabase")
 
 	var repoClient interfaces.ClientRepo
-	repoUsers := postgres.InitConnection()
+	var repoCustomFields interfaces.CustomFieldRepo
+	var repoContacts interfaces.ContactRepo
+	var repoActivities interfaces.ActivityRepo
+	var repoAttachments interfaces.AttachmentRepo
+	pg := postgres.InitConnection()
+	repoWebhooks := pg
+
+	// changes made through the repositories are published, whichever API made them
+	bus := events.NewBus()
+	repoUsers := events.Users(pg, bus)
 
 	switch usingDatabase {
 	case "postgres":
-		repoClient = postgres.InitConnection()
+		repo := postgres.InitConnection()
+		repoClient, repoCustomFields, repoContacts, repoActivities, repoAttachments = repo, repo, repo, repo, repo
 	case "mongo":
-		repoClient = mongodb.InitConnection()
+		repo := mongodb.InitConnection()
+		repoClient, repoCustomFields, repoContacts, repoActivities, repoAttachments = repo, repo, repo, repo, repo
 	default:
 		log.Fatal("Wrong value for usingDatabase parameter, check config")
 	}
+	repoClient = events.Clients(repoClient, bus)
 
-	handler, _ := handlers.NewClientHandler(repoClient)
-	userHandler, _ := handlers.NewUserHandler(repoUsers)
-	router := gin.Default()
-	router.GET("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), handler.GetClients)
-	router.GET("/clients/:id", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), handler.GetClientById)
-	router.POST("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "create"), handler.CreateClient)
-	router.PATCH("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "update"), handler.UpdateClient)
-	router.DELETE("/clients/:id", middleware.AuthForOperation(redisConn, repoUsers, "clients", "delete"), handler.DeleteClient)
-
-	router.GET("/users", userHandler.List)
-	router.GET("/users/:id", userHandler.ById)
-	router.POST("/users", userHandler.CreateUser)
-	router.PATCH("/users", userHandler.UpdateUser)
-	router.DELETE("/users/:id", userHandler.DeleteUser)
-
-	router.POST("/login", middleware.Login(userHandler, redisConn))
-	router.POST("/logout", middleware.Logout(redisConn))
-
-	newGraph, err := graph.NewGraph(repoClient)
+	var blobStore interfaces.BlobStore
+	switch utils.Conf.GetString("attachments.store") {
+	case "local":
+		blobStore = local.InitStore()
+	case "s3":
+		blobStore = s3.InitStore()
+	default:
+		log.Fatal("Wrong value for attachments.store parameter, check config")
+	}
+
+	go retention.Run(context.Background(),
+		utils.Conf.GetDuration("retention.period"),
+		utils.Conf.GetDuration("retention.interval"),
+		[]retention.Task{
+			{Name: "attachments", Purge: func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+				keys, err := repoAttachments.PurgeDeletedAttachments(ctx, deletedBefore)
+				for _, key := range keys {
+					if err := blobStore.Delete(ctx, key); err != nil {
+						log.Printf("retention: removing blob %s failed: %s", key, err)
+					}
+				}
+				return int64(len(keys)), err
+			}},
+			{Name: "activities", Purge: repoActivities.PurgeDeletedActivities},
+			{Name: "clients", Purge: repoClient.PurgeDeletedClients},
+			{Name: "users", Purge: repoUsers.PurgeDeletedUsers},
+			{Name: "webhook deliveries", Purge: repoWebhooks.PurgeDeliveries},
+		})
+
+	webhooks.Enqueue(bus, repoWebhooks)
+	hub := stream.NewHub(redisConn)
+	hub.Forward(bus)
+	go hub.Run(context.Background())
+	go webhooks.NewSender(repoWebhooks, webhooks.Config{
+		MaxAttempts: utils.Conf.GetInt("webhooks.maxAttempts"),
+		BaseDelay:   utils.Conf.GetDuration("webhooks.baseDelay"),
+		MaxDelay:    utils.Conf.GetDuration("webhooks.maxDelay"),
+		Timeout:     utils.Conf.GetDuration("webhooks.timeout"),
+		Interval:    utils.Conf.GetDuration("webhooks.interval"),
+		BatchSize:   utils.Conf.GetInt("webhooks.batchSize"),
+	}).Run(context.Background())
+
+	router, err := newRouter(redisConn, repoClient, repoCustomFields, repoContacts, repoActivities, repoAttachments, repoUsers, repoWebhooks, blobStore, hub)
 	if err != nil {
-		return
+		log.Fatal(err)
 	}
 
-	router.POST("/graph", middleware.Auth(redisConn), newGraph.GraphqlHandler)
+	grpcServer, err := rpc.NewServer(repoClient, repoCustomFields, repoUsers, redisConn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", utils.Conf.GetInt("grpc.port")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Printf("grpc server started on %s", grpcListener.Addr())
+		err := grpcServer.Serve(grpcListener)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
 
 	log.Println("application started on port 8080")
 	router.Run("127.0.0.1:8080")
 }
+
+// newRouter registers every API version on the given repositories.
+func newRouter(
+	redisConn *redis.Connection,
+	repoClient interfaces.ClientRepo,
+	repoCustomFields interfaces.CustomFieldRepo,
+	repoContacts interfaces.ContactRepo,
+	repoActivities interfaces.ActivityRepo,
+	repoAttachments interfaces.AttachmentRepo,
+	repoUsers interfaces.UserRepo,
+	repoWebhooks interfaces.WebhookRepo,
+	blobStore interfaces.BlobStore,
+	hub *stream.Hub,
+) (*gin.Engine, error) {
+
+	handler, _ := handlers.NewClientHandler(repoClient, repoCustomFields)
+	customFieldHandler, _ := handlers.NewCustomFieldHandler(repoCustomFields)
+	contactHandler, _ := handlers.NewContactHandler(repoContacts)
+	activityHandler, _ := handlers.NewActivityHandler(repoActivities, repoUsers)
+	attachmentHandler, err := handlers.NewAttachmentHandler(repoAttachments, blobStore)
+	if err != nil {
+		return nil, err
+	}
+	importHandler, err := handlers.NewImportHandler(repoClient, repoCustomFields, redisConn)
+	if err != nil {
+		return nil, err
+	}
+	exportHandler, _ := handlers.NewExportHandler(repoClient, repoCustomFields)
+	batchHandler, _ := handlers.NewBatchHandler(repoClient, repoCustomFields, repoUsers)
+	userHandler, _ := handlers.NewUserHandler(repoUsers)
+	webhookHandler, _ := handlers.NewWebhookHandler(repoWebhooks)
+	streamHandler, _ := handlers.NewStreamHandler(hub, repoUsers)
+	newGraph, err := graph.NewGraph(repoClient, repoCustomFields, repoContacts, repoActivities, repoUsers, hub, redisConn)
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.Default()
+	router.Use(middleware.RequestId(), middleware.Errors())
+
+	// every API version registers its own handlers on the shared repositories, a version with
+	// different DTOs gets its own entry here and the one it replaces is deprecated in the config
+	versions := map[string]func(api gin.IRouter){
+		"v1": func(api gin.IRouter) {
+			api.GET("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), middleware.IncludeDeleted(redisConn, repoUsers, "clients"), handler.GetClients)
+			api.GET("/clients/search", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), handler.SearchClients)
+			api.GET("/clients/duplicates", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), handler.GetDuplicates)
+			api.POST("/clients/merge", middleware.AuthForOperation(redisConn, repoUsers, "clients", "update"), middleware.AuthForOperation(redisConn, repoUsers, "clients", "delete"), handler.MergeClients)
+			api.POST("/clients/import", middleware.AuthForOperation(redisConn, repoUsers, "clients", "create"), importHandler.ImportClients)
+			api.GET("/clients/import/:jobId", middleware.AuthForOperation(redisConn, repoUsers, "clients", "create"), importHandler.GetImport)
+			api.GET("/clients/import/:jobId/report", middleware.AuthForOperation(redisConn, repoUsers, "clients", "create"), importHandler.GetImportReport)
+			api.GET("/clients/export", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), middleware.IncludeDeleted(redisConn, repoUsers, "clients"), exportHandler.ExportClients)
+			api.POST("/clients/batch", middleware.Auth(redisConn), batchHandler.Batch)
+			api.GET("/clients/stream", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), streamHandler.ClientEvents)
+			api.GET("/clients/stream/ws", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), streamHandler.ClientSocket)
+			api.GET("/clients/:id", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), middleware.IncludeDeleted(redisConn, repoUsers, "clients"), handler.GetClientById)
+			api.POST("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "create"), middleware.Idempotent(redisConn), handler.CreateClient)
+			api.PATCH("/clients", middleware.AuthForOperation(redisConn, repoUsers, "clients", "update"), handler.UpdateClient)
+			api.PATCH("/clients/:id", middleware.AuthForOperation(redisConn, repoUsers, "clients", "update"), handler.PatchClient)
+			api.DELETE("/clients/:id", middleware.AuthForOperation(redisConn, repoUsers, "clients", "delete"), handler.DeleteClient)
+			api.POST("/clients/:id/restore", middleware.AuthForOperation(redisConn, repoUsers, "clients", "delete"), handler.RestoreClient)
+			api.GET("/clients/:id/merges", middleware.AuthForOperation(redisConn, repoUsers, "clients", "read"), handler.GetMerges)
+
+			api.GET("/clients/:id/contacts", middleware.AuthForOperation(redisConn, repoUsers, "contacts", "read"), contactHandler.GetContacts)
+			api.GET("/clients/:id/contacts/:contactId", middleware.AuthForOperation(redisConn, repoUsers, "contacts", "read"), contactHandler.GetContactById)
+			api.POST("/clients/:id/contacts", middleware.AuthForOperation(redisConn, repoUsers, "contacts", "create"), contactHandler.CreateContact)
+			api.PATCH("/clients/:id/contacts/:contactId", middleware.AuthForOperation(redisConn, repoUsers, "contacts", "update"), contactHandler.UpdateContact)
+			api.DELETE("/clients/:id/contacts/:contactId", middleware.AuthForOperation(redisConn, repoUsers, "contacts", "delete"), contactHandler.DeleteContact)
+
+			api.GET("/clients/:id/activities", middleware.AuthForOperation(redisConn, repoUsers, "activities", "read"), activityHandler.GetActivities)
+			api.POST("/clients/:id/activities", middleware.AuthForOperation(redisConn, repoUsers, "activities", "create"), activityHandler.CreateActivity)
+			api.PATCH("/clients/:id/activities/:activityId", middleware.AuthForOperation(redisConn, repoUsers, "activities", "update"), activityHandler.UpdateActivity)
+			api.DELETE("/clients/:id/activities/:activityId", middleware.AuthForOperation(redisConn, repoUsers, "activities", "delete"), activityHandler.DeleteActivity)
+
+			api.GET("/clients/:id/attachments", middleware.AuthForOperation(redisConn, repoUsers, "attachments", "read"), attachmentHandler.GetAttachments)
+			api.GET("/clients/:id/attachments/:attachmentId", middleware.AuthForOperation(redisConn, repoUsers, "attachments", "read"), attachmentHandler.DownloadAttachment)
+			api.POST("/clients/:id/attachments", middleware.AuthForOperation(redisConn, repoUsers, "attachments", "create"), attachmentHandler.UploadAttachment)
+			api.DELETE("/clients/:id/attachments/:attachmentId", middleware.AuthForOperation(redisConn, repoUsers, "attachments", "delete"), attachmentHandler.DeleteAttachment)
+
+			api.GET("/customFields", middleware.AuthForOperation(redisConn, repoUsers, "customFields", "read"), customFieldHandler.GetCustomFields)
+			api.POST("/customFields", middleware.AuthForOperation(redisConn, repoUsers, "customFields", "create"), customFieldHandler.CreateCustomField)
+			api.PATCH("/customFields", middleware.AuthForOperation(redisConn, repoUsers, "customFields", "update"), customFieldHandler.UpdateCustomField)
+			api.DELETE("/customFields/:id", middleware.AuthForOperation(redisConn, repoUsers, "customFields", "delete"), customFieldHandler.DeleteCustomField)
+
+			api.GET("/users", middleware.IncludeDeleted(redisConn, repoUsers, "users"), userHandler.List)
+			api.GET("/users/:id", middleware.IncludeDeleted(redisConn, repoUsers, "users"), userHandler.ById)
+			api.POST("/users", middleware.Idempotent(redisConn), userHandler.CreateUser)
+			api.PATCH("/users", userHandler.UpdateUser)
+			api.PATCH("/users/:id", middleware.AuthForOperation(redisConn, repoUsers, "users", "update"), userHandler.PatchUser)
+			api.DELETE("/users/:id", userHandler.DeleteUser)
+			api.POST("/users/:id/restore", middleware.AuthForOperation(redisConn, repoUsers, "users", "delete"), userHandler.RestoreUser)
+
+			api.GET("/webhooks", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "read"), webhookHandler.GetWebhooks)
+			api.GET("/webhooks/:id", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "read"), webhookHandler.GetWebhookById)
+			api.POST("/webhooks", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "create"), webhookHandler.CreateWebhook)
+			api.PATCH("/webhooks", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "update"), webhookHandler.UpdateWebhook)
+			api.DELETE("/webhooks/:id", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "delete"), webhookHandler.DeleteWebhook)
+			api.GET("/webhooks/:id/deliveries", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "read"), webhookHandler.GetDeliveries)
+			api.POST("/webhooks/:id/deliveries/:deliveryId/retry", middleware.AuthForOperation(redisConn, repoUsers, "webhooks", "update"), webhookHandler.RetryDelivery)
+
+			api.POST("/login", middleware.Login(userHandler, redisConn))
+			api.POST("/logout", middleware.Logout(redisConn))
+
+			api.POST("/graph", middleware.Auth(redisConn), newGraph.GraphqlHandler)
+			api.GET("/graph", newGraph.SubscriptionHandler)
+
+			api.GET("/openapi.json", openapi.Handler("/api/v1", openapi.Operations))
+			api.GET("/docs", openapi.Docs)
+		},
+	}
+	for name, register := range versions {
+		register(router.Group("/api/"+name, deprecation("api.versions."+name)))
+	}
+	// the unversioned paths stay as aliases of an older version until they are sunset
+	if register, ok := versions[utils.Conf.GetString("api.unversioned.alias")]; ok {
+		register(router.Group("", deprecation("api.unversioned")))
+	}
+
+	return router, nil
+}
+
+// deprecation reads the deprecation, sunset and successor of a set of routes from the config at key.
+func deprecation(key string) gin.HandlerFunc {
+	return middleware.Deprecation(
+		utils.Conf.GetTime(key+".deprecation"),
+		utils.Conf.GetTime(key+".sunset"),
+		utils.Conf.GetString(key+".successor"),
+	)
+}
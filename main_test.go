@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"testApplication/openapi"
+	"testApplication/utils"
+	"testing"
+)
+
+// TestRoutesDocumented fails when a route is served that the OpenAPI document does not describe.
+func TestRoutesDocumented(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	utils.LoadConf()
+
+	router, err := newRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if missing := openapi.Undocumented(router.Routes(), "/api/v1", openapi.Operations); len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI document: %v", missing)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"log"
+	"regexp"
+	"testApplication/apperrors"
+)
+
+// validRequestId limits the request ids taken over from a proxy to a safe alphabet and length.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestId tags every request with the X-Request-Id sent by a proxy or a new random one, and echoes it.
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		requestId := c.GetHeader("X-Request-Id")
+		if !validRequestId.MatchString(requestId) {
+			id := make([]byte, 16)
+			rand.Read(id)
+			requestId = hex.EncodeToString(id)
+		}
+
+		c.Set("requestId", requestId)
+		c.Header("X-Request-Id", requestId)
+		c.Next()
+	}
+}
+
+// Errors answers the last error a handler added with c.Error as an application/problem+json document.
+// Errors that were never classified become a 500 whose cause is only logged.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeProblem(c)
+	}
+}
+
+// writeProblem renders the last error unless the response was already written, middlewares that need
+// the final response call it before Errors gets the chance.
+func writeProblem(c *gin.Context) {
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := c.Errors.Last().Err
+	requestId := c.GetString("requestId")
+
+	problem := apperrors.NewProblem(err, c.Request.URL.Path, requestId)
+	if problem.Code == apperrors.KindInternal {
+		log.Printf("request %s: %s", requestId, err)
+	}
+
+	body, err := json.MarshalIndent(problem, "", "    ")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c.Data(problem.Status, apperrors.ProblemContentType, body)
+}
+
+// fail hands err to Errors and stops the chain.
+func fail(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"testApplication/apperrors"
+	"testApplication/redis"
+	"testApplication/utils"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header.
+const maxIdempotencyKeyLength = 255
+
+// recordingWriter keeps a copy of the response body next to sending it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes a POST safe to retry with an Idempotency-Key header: the first response is kept for
+// idempotency.window per user and key, a replay gets it back, a replay while the first request still runs
+// gets 409 and a different request with a used key gets 422. Server errors are not kept so they can be retried.
+// Keys are kept per user, so it has to run after authentication on routes that have it. Anonymous callers can
+// not be told apart, their keys are scoped to the request itself: only the same key with the same body replays.
+func Idempotent(redisConn *redis.Connection) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			fail(c, apperrors.BadRequest("Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			fail(c, apperrors.Wrap(apperrors.KindBadRequest, err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		userId := c.GetInt("userId")
+		scope := strconv.Itoa(userId)
+		if userId == 0 {
+			scope = "anonymous:" + requestHash
+		}
+
+		stored, err := redisConn.StartIdempotent(c, scope, key, requestHash, utils.Conf.GetDuration("idempotency.lockTimeout"))
+		switch {
+		case err == redis.ErrIdempotencyInProgress:
+			fail(c, apperrors.Wrap(apperrors.KindConflict, err))
+			return
+		case err == redis.ErrIdempotencyMismatch:
+			fail(c, apperrors.Wrap(apperrors.KindUnprocessable, err))
+			return
+		case err != nil:
+			fail(c, err)
+			return
+		case stored != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		writeProblem(c)
+
+		if writer.Status() >= http.StatusInternalServerError {
+			err = redisConn.ReleaseIdempotent(c, scope, key)
+		} else {
+			err = redisConn.FinishIdempotent(c, scope, key, requestHash, redis.IdempotentResponse{
+				Status:      writer.Status(),
+				ContentType: writer.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+			}, utils.Conf.GetDuration("idempotency.window"))
+		}
+		if err != nil {
+			log.Printf("idempotency: keeping the response for key %s failed: %s", key, err)
+		}
+	}
+}
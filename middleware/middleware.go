@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"testApplication/apperrors"
 	"testApplication/handlers"
 	"testApplication/interfaces"
 	"testApplication/models"
@@ -30,24 +31,22 @@ func Auth(redisConn *redis.Connection) gin.HandlerFunc {
 
 		token := getToken(c)
 		if token == "" {
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "empty token"})
-			c.Abort()
+			fail(c, apperrors.New(apperrors.KindUnauthorized, "empty token"))
 			return
 		}
 
-		_, err := redisConn.CheckToken(c, token)
+		userId, err := redisConn.CheckToken(c, token)
 		if err != nil {
 			log.Println(err)
 			if err == redis.ErrUnauthorized {
-				c.IndentedJSON(http.StatusUnauthorized, gin.H{})
-				c.Abort()
+				fail(c, apperrors.New(apperrors.KindUnauthorized, "invalid or expired token"))
 				return
 			}
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err})
-			c.Abort()
+			fail(c, err)
 			return
 		}
 
+		c.Set("userId", userId)
 		c.Next()
 		return
 	}
@@ -59,44 +58,82 @@ func AuthForOperation(redisConn *redis.Connection, userRepo interfaces.UserRepo,
 		token := getToken(c)
 		log.Printf("authentication attempt from %s, token: %s", c.ClientIP(), token)
 		if token == "" {
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "empty token"})
-			c.Abort()
+			fail(c, apperrors.New(apperrors.KindUnauthorized, "empty token"))
 			return
 		}
 		userId, err := redisConn.CheckToken(c, token)
 		if err != nil {
 			log.Println(err)
 			if err == redis.ErrUnauthorized {
-				c.IndentedJSON(http.StatusUnauthorized, gin.H{})
+				fail(c, apperrors.New(apperrors.KindUnauthorized, "invalid or expired token"))
 				log.Printf("authentication failed from %s, token: %s", c.ClientIP(), token)
-				c.Abort()
 				return
 			}
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err})
+			fail(c, err)
 			log.Printf("authentication failed from %s, token: %s", c.ClientIP(), token)
-			c.Abort()
 			return
 		}
 
 		grant, err := userRepo.CheckUserGrant(c, userId, table, operation)
 		if err != nil {
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err})
+			fail(c, err)
 			log.Printf("authentication failed from %s, token: %s, user id: %d", c.ClientIP(), token, userId)
-			c.Abort()
 			return
 		}
 		if grant {
 			log.Printf("authentication successfull from %s, token: %s, user id: %d", c.ClientIP(), token, userId)
+			c.Set("userId", userId)
 			c.Next()
 			return
 		}
-		c.IndentedJSON(http.StatusUnauthorized, gin.H{})
-		c.Abort()
+		fail(c, apperrors.Forbidden("not allowed to %s %s", operation, table))
 		log.Printf("authentication failed from %s, token: %s, user id: %d", c.ClientIP(), token, userId)
 		return
 	}
 }
 
+// IncludeDeleted lets a caller see soft-deleted rows with ?includeDeleted=true,
+// which requires a "delete" grant on the table.
+func IncludeDeleted(redisConn *redis.Connection, userRepo interfaces.UserRepo, table string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		if c.Query("includeDeleted") != "true" {
+			c.Next()
+			return
+		}
+
+		token := getToken(c)
+		if token == "" {
+			fail(c, apperrors.New(apperrors.KindUnauthorized, "empty token"))
+			return
+		}
+		userId, err := redisConn.CheckToken(c, token)
+		if err != nil {
+			log.Println(err)
+			if err == redis.ErrUnauthorized {
+				fail(c, apperrors.New(apperrors.KindUnauthorized, "invalid or expired token"))
+				return
+			}
+			fail(c, err)
+			return
+		}
+
+		grant, err := userRepo.CheckUserGrant(c, userId, table, "delete")
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !grant {
+			log.Printf("includeDeleted denied for user id: %d on %s", userId, table)
+			fail(c, apperrors.Forbidden("not allowed to view deleted rows"))
+			return
+		}
+
+		c.Set("includeDeleted", true)
+		c.Next()
+	}
+}
+
 func generateSecureToken(email string) string {
 	sha := crypto.SHA256.New()
 	secureString := fmt.Sprint(email, time.Now().Unix())
@@ -108,7 +145,7 @@ func Login(userHandler *handlers.UserHandler, redisConn *redis.Connection) gin.H
 	return func(c *gin.Context) {
 
 		var user models.User
-		err := c.Bind(&user)
+		err := c.ShouldBind(&user)
 
 		userFromDb, err := userHandler.Repo.ByEmail(c, user.Email)
 		log.Printf("authorization attempt from %s, email: %s", c.ClientIP(), user.Email)
@@ -116,16 +153,16 @@ func Login(userHandler *handlers.UserHandler, redisConn *redis.Connection) gin.H
 		if err != nil {
 			if err == interfaces.ErrNoRows {
 				log.Printf("authorization failed from %s, email: %s", c.ClientIP(), user.Email)
-				c.IndentedJSON(http.StatusUnauthorized, gin.H{"message": "Wrong credentials"})
+				fail(c, apperrors.New(apperrors.KindUnauthorized, "wrong credentials"))
 				return
 			}
 			log.Printf("authorization failed from %s, email: %s", c.ClientIP(), user.Email)
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err})
+			fail(c, err)
 			return
 		}
 		if compare := bcrypt.CompareHashAndPassword([]byte(userFromDb.Password), []byte(user.Password)); compare != nil {
 			log.Printf("authorization failed from %s, email: %s", c.ClientIP(), user.Email)
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"message": "Wrong credentials"})
+			fail(c, apperrors.New(apperrors.KindUnauthorized, "wrong credentials"))
 			return
 		}
 
@@ -133,7 +170,7 @@ func Login(userHandler *handlers.UserHandler, redisConn *redis.Connection) gin.H
 
 		err = redisConn.AddToken(c, userFromDb, token)
 		if err != nil {
-			c.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err})
+			fail(c, err)
 			log.Printf("authorization failed from %s, email: %s, internal server error %s", c.ClientIP(), user.Email, err)
 			return
 		}
@@ -148,12 +185,13 @@ func Logout(redisConn *redis.Connection) gin.HandlerFunc {
 
 		token := c.GetHeader("token")
 		if token == "" {
-			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "empty token"})
+			fail(c, apperrors.BadRequest("empty token"))
+			return
 		}
 
 		err := redisConn.RemoveToken(c, token)
 		if err != nil {
-			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": err})
+			fail(c, apperrors.Wrap(apperrors.KindBadRequest, err))
 			return
 		}
 
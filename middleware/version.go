@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"testApplication/apperrors"
+	"time"
+)
+
+// Deprecation announces that the routes behind it are going away: from deprecatedAt on every response
+// carries a Deprecation header, a Sunset header when sunsetAt is set and a Link to the same path under
+// successor. Once sunsetAt has passed the routes answer 410. Zero times leave the routes untouched.
+func Deprecation(deprecatedAt time.Time, sunsetAt time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		now := time.Now()
+		if deprecatedAt.IsZero() || now.Before(deprecatedAt) {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		if !sunsetAt.IsZero() {
+			c.Header("Sunset", sunsetAt.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			// Add, the handler may send links of its own
+			c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successorPath(c.Request.URL.Path, successor)))
+		}
+
+		if !sunsetAt.IsZero() && !now.Before(sunsetAt) {
+			fail(c, apperrors.New(apperrors.KindGone, "this API version was retired on %s, use %s", sunsetAt.UTC().Format(time.DateOnly), successor))
+			return
+		}
+		c.Next()
+	}
+}
+
+// successorPath moves path from its version prefix, if any, to successor: /clients and /api/v1/clients
+// both become /api/v2/clients for successor /api/v2.
+func successorPath(path string, successor string) string {
+	if strings.HasPrefix(path, "/api/") {
+		if rest := strings.TrimPrefix(path, "/api/"); strings.Contains(rest, "/") {
+			path = rest[strings.Index(rest, "/"):]
+		}
+	}
+	return successor + path
+}
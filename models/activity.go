@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	ActivityCall    = "call"
+	ActivityMeeting = "meeting"
+	ActivityComment = "comment"
+)
+
+var ActivityKinds = []string{ActivityCall, ActivityMeeting, ActivityComment}
+
+// AdminRole is the role whose members may edit and delete anyone's records.
+const AdminRole = "admin"
+
+type Activity struct {
+	Id        int        `json:"id"`
+	ClientId  int        `json:"clientId" bson:"clientId"`
+	AuthorId  int        `json:"authorId" bson:"authorId"`
+	Kind      string     `json:"kind"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// ActivityCursor points at the last activity of a timeline page, the zero value starts from the newest one.
+type ActivityCursor struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Id        int       `json:"id"`
+}
+
+type ActivityPage struct {
+	Items      []Activity `json:"items"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
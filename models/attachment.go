@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type Attachment struct {
+	Id          int        `json:"id"`
+	ClientId    int        `json:"clientId" bson:"clientId"`
+	FileName    string     `json:"fileName" bson:"fileName"`
+	ContentType string     `json:"contentType" bson:"contentType"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"checksum"`
+	StorageKey  string     `json:"-" bson:"storageKey"`
+	UploadedBy  int        `json:"uploadedBy" bson:"uploadedBy"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
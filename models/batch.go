@@ -0,0 +1,30 @@
+package models
+
+const (
+	ClientOpCreate = "create"
+	ClientOpUpdate = "update"
+	ClientOpDelete = "delete"
+)
+
+var ClientOps = []string{ClientOpCreate, ClientOpUpdate, ClientOpDelete}
+
+// ClientOperation is one step of a batch, Client carries the values to create or update
+// and Id the client to update or delete. A non-zero Version guards a delete the way
+// Client.Version guards an update.
+type ClientOperation struct {
+	Op      string `json:"op"`
+	Id      int    `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Client  Client `json:"client"`
+}
+
+// ClientOperationResult reports an operation of a batch with an HTTP-like status.
+type ClientOperationResult struct {
+	Index  int     `json:"index"`
+	Op     string  `json:"op"`
+	Status int     `json:"status"`
+	Id     int     `json:"id,omitempty"`
+	Client *Client `json:"client,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Errors error   `json:"errors,omitempty"`
+}
@@ -1,6 +1,92 @@
 package models
 
+import "time"
+
+const (
+	ClientStatusLead     = "lead"
+	ClientStatusActive   = "active"
+	ClientStatusArchived = "archived"
+)
+
+var ClientStatuses = []string{ClientStatusLead, ClientStatusActive, ClientStatusArchived}
+
 type Client struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id        int        `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Phone     string     `json:"phone"`
+	Address   string     `json:"address"`
+	TaxId     string     `json:"taxId" bson:"taxId"`
+	Status    string     `json:"status"`
+	Tags      []string   `json:"tags"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Version grows with every change, an update or delete carrying a non-zero
+	// version only applies while the stored client still has it.
+	Version int `json:"version"`
+
+	CustomFields map[string]interface{} `json:"customFields" bson:"customFields"`
+}
+
+// ClientPatchFields are the client fields a patch may change, the others are kept by the server.
+var ClientPatchFields = []string{"name", "email", "phone", "address", "taxId", "status", "tags", "customFields"}
+
+// WithDefaults fills the fields a new client may omit.
+func (client Client) WithDefaults() Client {
+	if client.Status == "" {
+		client.Status = ClientStatusLead
+	}
+	if client.Tags == nil {
+		client.Tags = []string{}
+	}
+	if client.CustomFields == nil {
+		client.CustomFields = map[string]interface{}{}
+	}
+	return client
+}
+
+type ClientPage struct {
+	Items      []Client `json:"items"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+	TotalCount *int64   `json:"totalCount,omitempty"`
+}
+
+// ClientSearchResult is a search hit, Highlights holds the matched fields with the matching words in <mark> tags.
+type ClientSearchResult struct {
+	Client     Client            `json:"client"`
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// DuplicateMatch is a client that is likely the same company as another one,
+// Reasons lists the signals that matched.
+type DuplicateMatch struct {
+	Client  Client   `json:"client"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type DuplicateGroup struct {
+	Client     Client           `json:"client"`
+	Duplicates []DuplicateMatch `json:"duplicates"`
+}
+
+// DuplicateQuery prefilters the clients worth scoring as duplicates,
+// the values are normalized and empty ones are ignored.
+type DuplicateQuery struct {
+	ExcludeId   int
+	NameTerms   []string
+	TaxId       string
+	EmailDomain string
+}
+
+// ClientMerge records a client merged into another one, Source is the merged client as it was.
+type ClientMerge struct {
+	Id       int       `json:"id"`
+	TargetId int       `json:"targetId" bson:"targetId"`
+	SourceId int       `json:"sourceId" bson:"sourceId"`
+	MergedBy int       `json:"mergedBy" bson:"mergedBy"`
+	MergedAt time.Time `json:"mergedAt" bson:"mergedAt"`
+	Source   Client    `json:"source"`
 }
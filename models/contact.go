@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Contact struct {
+	Id        int        `json:"id"`
+	ClientId  int        `json:"clientId" bson:"clientId"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Phone     string     `json:"phone"`
+	Position  string     `json:"position"`
+	Primary   bool       `json:"primary"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+const (
+	CustomFieldString = "string"
+	CustomFieldNumber = "number"
+	CustomFieldDate   = "date"
+	CustomFieldEnum   = "enum"
+	CustomFieldBool   = "bool"
+)
+
+var CustomFieldTypes = []string{CustomFieldString, CustomFieldNumber, CustomFieldDate, CustomFieldEnum, CustomFieldBool}
+
+type CustomField struct {
+	Id         int      `json:"id"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enumValues" bson:"enumValues"`
+}
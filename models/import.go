@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportRowError is a rejected value, Row counts lines of the file with the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ImportJob tracks a client import. ImportedRows grows while the valid rows are stored
+// in one transaction, none of them is visible before all of them are.
+type ImportJob struct {
+	Id            string           `json:"id"`
+	Status        string           `json:"status"`
+	DryRun        bool             `json:"dryRun"`
+	FileName      string           `json:"fileName"`
+	CreatedBy     int              `json:"createdBy"`
+	ProcessedRows int              `json:"processedRows"`
+	ValidRows     int              `json:"validRows"`
+	InvalidRows   int              `json:"invalidRows"`
+	ImportedRows  int              `json:"importedRows"`
+	Errors        []ImportRowError `json:"errors"`
+	Error         string           `json:"error,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	FinishedAt    *time.Time       `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "testApplication/query"
+
+type ListQuery struct {
+	Offset         int
+	Limit          int
+	IncludeDeleted bool
+	Filter         query.Expr
+	Sort           []query.Sort
+}
@@ -1,9 +1,15 @@
 package models
 
+import "time"
+
 type User struct {
-	Id       int
-	Email    string
-	Name     string
-	Password string
-	Roles    []Role
+	Id        int
+	Email     string
+	Name      string
+	Password  string
+	Roles     []Role
+	DeletedAt *time.Time
 }
+
+// UserPatchFields are the user fields a patch may change, a patched password is stored hashed.
+var UserPatchFields = []string{"name", "email", "password"}
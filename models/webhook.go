@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+var DeliveryStatuses = []string{DeliveryPending, DeliveryDelivered, DeliveryDead}
+
+// Webhook subscribes a URL to event types. Secret signs the deliveries, it is only shown once
+// when the webhook is created.
+type Webhook struct {
+	Id        int       `json:"id"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedBy int       `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery is an event queued for a webhook. A pending delivery is attempted at NextAttemptAt,
+// one that kept failing ends up dead and is only sent again when retried by hand.
+type WebhookDelivery struct {
+	Id             int64           `json:"id"`
+	WebhookId      int             `json:"webhookId"`
+	EventId        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
@@ -0,0 +1,236 @@
+package openapi
+
+import (
+	_ "embed"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testApplication/apperrors"
+)
+
+// Operation documents a route. Body and Response are zero values of the types sent and
+// answered, their schemas are derived from the json and validate tags.
+type Operation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Auth        bool
+	Query       []Param
+	Headers     []Param
+	Body        interface{}
+	ContentType string
+	Status      int
+	Response    interface{}
+	Produces    string
+}
+
+// Param is a query or header parameter, Type is a JSON schema type.
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Envelope is the object most mutations answer, the status member next to the named values.
+type Envelope map[string]interface{}
+
+// Object is an inline object or form without a Go type of its own.
+type Object map[string]interface{}
+
+// Binary stands for a response that is a file rather than JSON.
+type Binary struct{}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z]+)`)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Document builds the OpenAPI 3.1 description of the operations served under the server path.
+func Document(server string, operations []Operation) map[string]interface{} {
+
+	components := schemas{}
+	problem := components.ref(apperrors.Problem{})
+	paths := map[string]interface{}{}
+
+	for _, operation := range operations {
+		path := pathParam.ReplaceAllString(operation.Path, "{$1}")
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(operation.Method)] = describe(operation, components, problem)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "Clients API",
+			"version": "1.0.0",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": server}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func describe(operation Operation, components schemas, problem map[string]interface{}) map[string]interface{} {
+
+	var parameters []interface{}
+	for _, name := range pathParam.FindAllStringSubmatch(operation.Path, -1) {
+		schema := map[string]interface{}{"type": "integer", "exclusiveMinimum": 0}
+		if strings.HasSuffix(name[1], "jobId") {
+			schema = map[string]interface{}{"type": "string"}
+		}
+		parameters = append(parameters, map[string]interface{}{"name": name[1], "in": "path", "required": true, "schema": schema})
+	}
+	for _, param := range operation.Query {
+		parameters = append(parameters, parameter(param, "query"))
+	}
+	for _, param := range operation.Headers {
+		parameters = append(parameters, parameter(param, "header"))
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	described := map[string]interface{}{
+		"tags":        []string{operation.Tag},
+		"summary":     operation.Summary,
+		"operationId": operationId(operation),
+		"responses": map[string]interface{}{
+			strconv.Itoa(status): response(operation, components),
+			"default": map[string]interface{}{
+				"description": "the error as RFC 7807 problem details",
+				"content":     map[string]interface{}{apperrors.ProblemContentType: map[string]interface{}{"schema": problem}},
+			},
+		},
+	}
+	if len(parameters) > 0 {
+		described["parameters"] = parameters
+	}
+	if operation.Auth {
+		described["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	}
+	if operation.Body != nil {
+		contentType := operation.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		described["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{contentType: map[string]interface{}{"schema": schema(operation.Body, components)}},
+		}
+	}
+	return described
+}
+
+func parameter(param Param, in string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        param.Name,
+		"in":          in,
+		"required":    param.Required,
+		"description": param.Description,
+		"schema":      map[string]interface{}{"type": param.Type},
+	}
+}
+
+func response(operation Operation, components schemas) map[string]interface{} {
+
+	described := map[string]interface{}{"description": operation.Summary}
+	if operation.Response == nil {
+		return described
+	}
+	contentType := operation.Produces
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	described["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": schema(operation.Response, components)}}
+	return described
+}
+
+// schema describes a body, envelopes and binaries have no Go type of their own.
+func schema(value interface{}, components schemas) map[string]interface{} {
+
+	switch value := value.(type) {
+	case Binary:
+		return map[string]interface{}{"type": "string", "format": "binary"}
+	case Envelope:
+		properties := map[string]interface{}{"status": map[string]interface{}{"type": "string"}}
+		for name, member := range value {
+			properties[name] = schema(member, components)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "required": []string{"status"}}
+	case Object:
+		// an inline object or form, the members are its fields
+		properties := map[string]interface{}{}
+		for name, member := range value {
+			properties[name] = schema(member, components)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+	return components.ref(value)
+}
+
+// operationId is the method followed by the static path segments and ById when the path ends
+// with a parameter, GET /clients/:id/contacts/:contactId is getClientsContactsById.
+func operationId(operation Operation) string {
+	id := strings.ToLower(operation.Method)
+	segments := strings.Split(operation.Path, "/")
+	for _, segment := range segments {
+		if segment == "" || strings.HasPrefix(segment, ":") {
+			continue
+		}
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	if strings.HasPrefix(segments[len(segments)-1], ":") {
+		id += "ById"
+	}
+	return id
+}
+
+// Handler serves the document as JSON.
+func Handler(server string, operations []Operation) gin.HandlerFunc {
+	document := Document(server, operations)
+	return func(c *gin.Context) {
+		c.IndentedJSON(http.StatusOK, document)
+	}
+}
+
+// Docs serves the interactive documentation reading /openapi.json.
+func Docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
+
+// Undocumented lists the routes registered under the server path that are missing from the operations.
+func Undocumented(routes gin.RoutesInfo, server string, operations []Operation) []string {
+
+	documented := map[string]bool{}
+	for _, operation := range operations {
+		documented[operation.Method+" "+operation.Path] = true
+	}
+
+	var missing []string
+	for _, route := range routes {
+		path, ok := strings.CutPrefix(route.Path, server)
+		if !ok || !strings.HasPrefix(path, "/") {
+			continue
+		}
+		if !documented[route.Method+" "+path] {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"net/http"
+	"testApplication/dto"
+	"testApplication/events"
+	"testApplication/models"
+	"testApplication/patch"
+)
+
+var (
+	limitParam          = Param{Name: "limit", Type: "integer", Description: "page size, capped by the server"}
+	afterParam          = Param{Name: "after", Type: "string", Description: "cursor of the next page"}
+	filterParam         = Param{Name: "filter", Type: "string", Description: "filter expression, e.g. status eq 'active' and tags contains 'vip'"}
+	sortParam           = Param{Name: "sort", Type: "string", Description: "comma separated fields, a leading - sorts descending"}
+	includeDeletedParam = Param{Name: "includeDeleted", Type: "boolean", Description: "include soft deleted records, needs the delete grant"}
+	ifMatchHeader       = Param{Name: "If-Match", Type: "string", Description: "ETag the change applies to"}
+	idempotencyHeader   = Param{Name: "Idempotency-Key", Type: "string", Description: "replays the first response for a repeated key"}
+	lastEventIdHeader   = Param{Name: "Last-Event-ID", Type: "string", Description: "id of the last received change, the changes after it are sent first"}
+	lastEventIdParam    = Param{Name: "lastEventId", Type: "string", Description: "Last-Event-ID for clients that can not set headers"}
+)
+
+var clientPatch = Object{"name": "", "email": "", "phone": "", "address": "", "taxId": "", "status": "", "tags": []string{}, "customFields": map[string]interface{}{}}
+
+// clientUpdate is the body of PATCH /clients, the fields left out keep their stored values.
+var clientUpdate = Object{"id": 0, "version": 0, "name": "", "email": "", "phone": "", "address": "", "taxId": "", "status": "", "tags": []string{}, "customFields": map[string]interface{}{}}
+
+// Operations documents every route of the API, a test fails when a registered route is missing.
+var Operations = []Operation{
+	{Method: http.MethodGet, Path: "/clients", Tag: "clients", Summary: "List clients", Auth: true,
+		Query:    []Param{limitParam, afterParam, filterParam, sortParam, includeDeletedParam, {Name: "totalCount", Type: "boolean", Description: "count all matching clients"}},
+		Response: models.ClientPage{}},
+	{Method: http.MethodGet, Path: "/clients/search", Tag: "clients", Summary: "Search clients by full text", Auth: true,
+		Query:    []Param{{Name: "q", Type: "string", Required: true}, limitParam},
+		Response: Object{"items": []models.ClientSearchResult{}}},
+	{Method: http.MethodGet, Path: "/clients/duplicates", Tag: "clients", Summary: "List likely duplicate clients", Auth: true,
+		Query:    []Param{limitParam, afterParam},
+		Response: Object{"items": []models.DuplicateGroup{}, "nextCursor": ""}},
+	{Method: http.MethodPost, Path: "/clients/merge", Tag: "clients", Summary: "Merge a client into another one", Auth: true,
+		Body: dto.MergeInput{}, Response: Envelope{"client": models.Client{}, "merge": models.ClientMerge{}}},
+	{Method: http.MethodPost, Path: "/clients/import", Tag: "imports", Summary: "Import clients from a CSV or XLSX file", Auth: true,
+		ContentType: "multipart/form-data", Body: Object{"file": Binary{}, "mapping": "", "dryRun": false, "async": false},
+		Response: models.ImportJob{}},
+	{Method: http.MethodGet, Path: "/clients/import/:jobId", Tag: "imports", Summary: "Get an import job", Auth: true,
+		Response: models.ImportJob{}},
+	{Method: http.MethodGet, Path: "/clients/import/:jobId/report", Tag: "imports", Summary: "Download the rejected rows of an import", Auth: true,
+		Response: Binary{}, Produces: "text/csv"},
+	{Method: http.MethodGet, Path: "/clients/export", Tag: "clients", Summary: "Export clients", Auth: true,
+		Query:    []Param{{Name: "format", Type: "string", Description: "csv, ndjson or xlsx"}, filterParam, sortParam, includeDeletedParam},
+		Response: Binary{}, Produces: "text/csv"},
+	{Method: http.MethodPost, Path: "/clients/batch", Tag: "clients", Summary: "Create, update and delete clients in one request", Auth: true,
+		Body:     Object{"mode": "", "operations": []models.ClientOperation{}},
+		Response: Envelope{"results": []models.ClientOperationResult{}}},
+	{Method: http.MethodGet, Path: "/clients/stream", Tag: "clients", Summary: "Stream client changes as Server-Sent Events", Auth: true,
+		Headers:  []Param{lastEventIdHeader},
+		Query:    []Param{lastEventIdParam},
+		Response: events.Event{}, Produces: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/clients/stream/ws", Tag: "clients", Summary: "Stream client changes over a WebSocket", Auth: true,
+		Headers: []Param{lastEventIdHeader},
+		Query:   []Param{lastEventIdParam},
+		Status:  http.StatusSwitchingProtocols},
+	{Method: http.MethodGet, Path: "/clients/:id", Tag: "clients", Summary: "Get a client", Auth: true,
+		Query:    []Param{includeDeletedParam},
+		Headers:  []Param{{Name: "If-None-Match", Type: "string"}},
+		Response: models.Client{}},
+	{Method: http.MethodPost, Path: "/clients", Tag: "clients", Summary: "Create a client", Auth: true,
+		Headers: []Param{idempotencyHeader},
+		Body:    dto.ClientInput{}, Response: Envelope{"client": models.Client{}, "warning": "", "duplicates": []models.DuplicateMatch{}}},
+	{Method: http.MethodPatch, Path: "/clients", Tag: "clients", Summary: "Update a client, omitted fields keep their values", Auth: true,
+		Headers: []Param{ifMatchHeader},
+		Body:    clientUpdate, Response: Envelope{"client": models.Client{}}},
+	{Method: http.MethodPatch, Path: "/clients/:id", Tag: "clients", Summary: "Patch a client, JSON patch documents are accepted as well", Auth: true,
+		Headers:     []Param{ifMatchHeader},
+		ContentType: patch.MergePatch, Body: clientPatch, Response: Envelope{"client": models.Client{}}},
+	{Method: http.MethodDelete, Path: "/clients/:id", Tag: "clients", Summary: "Delete a client", Auth: true,
+		Headers: []Param{ifMatchHeader}, Response: Envelope{}},
+	{Method: http.MethodPost, Path: "/clients/:id/restore", Tag: "clients", Summary: "Restore a deleted client", Auth: true,
+		Response: Envelope{"client": models.Client{}}},
+	{Method: http.MethodGet, Path: "/clients/:id/merges", Tag: "clients", Summary: "List the clients merged into a client", Auth: true,
+		Response: []models.ClientMerge{}},
+
+	{Method: http.MethodGet, Path: "/clients/:id/contacts", Tag: "contacts", Summary: "List the contacts of a client", Auth: true,
+		Response: []models.Contact{}},
+	{Method: http.MethodGet, Path: "/clients/:id/contacts/:contactId", Tag: "contacts", Summary: "Get a contact", Auth: true,
+		Response: models.Contact{}},
+	{Method: http.MethodPost, Path: "/clients/:id/contacts", Tag: "contacts", Summary: "Create a contact", Auth: true,
+		Body: dto.ContactInput{}, Response: Envelope{"contact": models.Contact{}}},
+	{Method: http.MethodPatch, Path: "/clients/:id/contacts/:contactId", Tag: "contacts", Summary: "Replace a contact", Auth: true,
+		Body: dto.ContactInput{}, Response: Envelope{"contact": models.Contact{}}},
+	{Method: http.MethodDelete, Path: "/clients/:id/contacts/:contactId", Tag: "contacts", Summary: "Delete a contact", Auth: true,
+		Response: Envelope{}},
+
+	{Method: http.MethodGet, Path: "/clients/:id/activities", Tag: "activities", Summary: "List the activities of a client, newest first", Auth: true,
+		Query:    []Param{limitParam, afterParam},
+		Response: models.ActivityPage{}},
+	{Method: http.MethodPost, Path: "/clients/:id/activities", Tag: "activities", Summary: "Log an activity", Auth: true,
+		Body: dto.ActivityInput{}, Response: Envelope{"activity": models.Activity{}}},
+	{Method: http.MethodPatch, Path: "/clients/:id/activities/:activityId", Tag: "activities", Summary: "Change an activity, only its author or an admin may", Auth: true,
+		Body: dto.ActivityInput{}, Response: Envelope{"activity": models.Activity{}}},
+	{Method: http.MethodDelete, Path: "/clients/:id/activities/:activityId", Tag: "activities", Summary: "Delete an activity, only its author or an admin may", Auth: true,
+		Response: Envelope{}},
+
+	{Method: http.MethodGet, Path: "/clients/:id/attachments", Tag: "attachments", Summary: "List the attachments of a client", Auth: true,
+		Response: []models.Attachment{}},
+	{Method: http.MethodGet, Path: "/clients/:id/attachments/:attachmentId", Tag: "attachments", Summary: "Download an attachment", Auth: true,
+		Response: Binary{}, Produces: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/clients/:id/attachments", Tag: "attachments", Summary: "Upload an attachment", Auth: true,
+		ContentType: "multipart/form-data", Body: Object{"file": Binary{}, "checksum": ""},
+		Response: Envelope{"attachment": models.Attachment{}}},
+	{Method: http.MethodDelete, Path: "/clients/:id/attachments/:attachmentId", Tag: "attachments", Summary: "Delete an attachment", Auth: true,
+		Response: Envelope{}},
+
+	{Method: http.MethodGet, Path: "/customFields", Tag: "customFields", Summary: "List the custom field definitions", Auth: true,
+		Response: []models.CustomField{}},
+	{Method: http.MethodPost, Path: "/customFields", Tag: "customFields", Summary: "Define a custom field", Auth: true,
+		Body: dto.CustomFieldInput{}, Response: Envelope{"customField": models.CustomField{}}},
+	{Method: http.MethodPatch, Path: "/customFields", Tag: "customFields", Summary: "Change a custom field definition", Auth: true,
+		Body: dto.CustomFieldUpdateInput{}, Response: Envelope{"customField": models.CustomField{}}},
+	{Method: http.MethodDelete, Path: "/customFields/:id", Tag: "customFields", Summary: "Delete a custom field definition", Auth: true,
+		Response: Envelope{}},
+
+	{Method: http.MethodGet, Path: "/users", Tag: "users", Summary: "List users",
+		Query:    []Param{{Name: "offset", Type: "integer"}, limitParam, filterParam, sortParam, includeDeletedParam},
+		Response: []models.User{}},
+	{Method: http.MethodGet, Path: "/users/:id", Tag: "users", Summary: "Get a user",
+		Query:    []Param{includeDeletedParam},
+		Response: models.User{}},
+	{Method: http.MethodPost, Path: "/users", Tag: "users", Summary: "Register a user",
+		Headers: []Param{idempotencyHeader},
+		Body:    dto.UserInput{}, Response: Envelope{"user": models.User{}}},
+	{Method: http.MethodPatch, Path: "/users", Tag: "users", Summary: "Rename a user",
+		Body: dto.UserUpdateInput{}, Response: Envelope{"user": models.User{}}},
+	{Method: http.MethodPatch, Path: "/users/:id", Tag: "users", Summary: "Patch a user, JSON patch documents are accepted as well", Auth: true,
+		ContentType: patch.MergePatch, Body: Object{"name": "", "email": "", "password": ""}, Response: Envelope{"user": models.User{}}},
+	{Method: http.MethodDelete, Path: "/users/:id", Tag: "users", Summary: "Delete a user",
+		Response: Envelope{"user": models.User{}}},
+	{Method: http.MethodPost, Path: "/users/:id/restore", Tag: "users", Summary: "Restore a deleted user", Auth: true,
+		Response: Envelope{"user": models.User{}}},
+
+	{Method: http.MethodGet, Path: "/webhooks", Tag: "webhooks", Summary: "List webhooks, secrets are left out", Auth: true,
+		Response: []models.Webhook{}},
+	{Method: http.MethodGet, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Get a webhook", Auth: true,
+		Response: models.Webhook{}},
+	{Method: http.MethodPost, Path: "/webhooks", Tag: "webhooks", Summary: "Subscribe a URL to events, the answer holds the signing secret", Auth: true,
+		Body: dto.WebhookInput{}, Response: Envelope{"webhook": models.Webhook{}}},
+	{Method: http.MethodPatch, Path: "/webhooks", Tag: "webhooks", Summary: "Change the url, events or active flag of a webhook", Auth: true,
+		Body: dto.WebhookUpdateInput{}, Response: Envelope{"webhook": models.Webhook{}}},
+	{Method: http.MethodDelete, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Delete a webhook and its deliveries", Auth: true,
+		Response: Envelope{}},
+	{Method: http.MethodGet, Path: "/webhooks/:id/deliveries", Tag: "webhooks", Summary: "Delivery log of a webhook, newest first", Auth: true,
+		Query:    []Param{{Name: "status", Type: "string", Description: "pending, delivered or dead"}, limitParam},
+		Response: []models.WebhookDelivery{}},
+	{Method: http.MethodPost, Path: "/webhooks/:id/deliveries/:deliveryId/retry", Tag: "webhooks", Summary: "Queue a dead delivery again", Auth: true,
+		Response: Envelope{"delivery": models.WebhookDelivery{}}},
+
+	{Method: http.MethodPost, Path: "/login", Tag: "auth", Summary: "Exchange credentials for a bearer token",
+		Body: Object{"email": "", "password": ""}, Response: Object{"token": ""}},
+	{Method: http.MethodPost, Path: "/logout", Tag: "auth", Summary: "Revoke a token",
+		Headers: []Param{{Name: "token", Type: "string", Required: true}}, Response: Object{"result": ""}},
+
+	{Method: http.MethodPost, Path: "/graph", Tag: "graphql", Summary: "Run a GraphQL query or mutation", Auth: true,
+		Body: Object{"query": "", "operation": "", "variables": map[string]interface{}{}}, Response: Object{"data": map[string]interface{}{}}},
+	{Method: http.MethodGet, Path: "/graph", Tag: "graphql", Summary: "Run GraphQL subscriptions, queries and mutations over a graphql-transport-ws or graphql-ws WebSocket, authenticated in connection_init",
+		Status: http.StatusSwitchingProtocols},
+
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "docs", Summary: "This document", Response: Object{}},
+	{Method: http.MethodGet, Path: "/docs", Tag: "docs", Summary: "Interactive documentation", Response: Binary{}, Produces: "text/html"},
+}
@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas collects the named types of the document, they are referenced by name from the operations.
+type schemas map[string]interface{}
+
+// ref describes the type of value, structs are added to the components and referenced.
+func (s schemas) ref(value interface{}) map[string]interface{} {
+	return s.of(reflect.TypeOf(value))
+}
+
+func (s schemas) of(t reflect.Type) map[string]interface{} {
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			// an error is rendered as its field errors
+			return map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}
+		}
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Uint, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s[t.Name()]; !ok {
+			// registered before it is described so recursive types end
+			s[t.Name()] = nil
+			s[t.Name()] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// object describes the struct the way encoding/json writes it, validate tags become constraints.
+func (s schemas) object(t reflect.Type) map[string]interface{} {
+
+	properties := map[string]interface{}{}
+	var required []string
+	s.fields(t, properties, &required)
+
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+func (s schemas) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.fields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := s.of(field.Type)
+		if rules, ok := field.Tag.Lookup("validate"); ok && constrain(schema, field.Type, rules) {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+// constrain adds the validate rules of a field to its schema, rules after dive apply to the items.
+// It tells whether the field is required.
+func constrain(schema map[string]interface{}, t reflect.Type, rules string) (required bool) {
+
+	before, after, dive := strings.Cut(","+rules, ",dive")
+	if dive {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			constrain(items, t.Elem(), strings.TrimPrefix(after, ","))
+		}
+	}
+
+	for _, rule := range strings.Split(strings.TrimPrefix(before, ","), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		number, _ := strconv.Atoi(param)
+		switch name {
+		case "required":
+			required = true
+		case "notblank":
+			required = true
+			schema["minLength"] = 1
+		case "email":
+			schema["format"] = "email"
+		case "httpurl":
+			required = true
+			schema["format"] = "uri"
+			schema["pattern"] = "^https?://"
+		case "e164":
+			schema["pattern"] = `^\+[1-9][0-9]{1,14}$`
+		case "oneof":
+			schema["enum"] = strings.Fields(param)
+		case "gt":
+			// the zero value is rejected, so the field can not be left out
+			required = number >= 0
+			schema["exclusiveMinimum"] = number
+		case "gte":
+			schema["minimum"] = number
+		case "min", "max":
+			schema[bound(name, t)] = number
+		}
+	}
+	return required
+}
+
+// bound names the min or max keyword for the kind of value it limits.
+func bound(rule string, t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return rule + "Length"
+	case reflect.Slice, reflect.Array:
+		return rule + "Items"
+	case reflect.Map:
+		return rule + "Properties"
+	}
+	if rule == "min" {
+		return "minimum"
+	}
+	return "maximum"
+}
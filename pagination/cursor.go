@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor turns a position in a result set into an opaque token for clients.
+func EncodeCursor(position interface{}) (string, error) {
+
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor reads a token produced by EncodeCursor into position.
+func DecodeCursor(cursor string, position interface{}) error {
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if json.Unmarshal(raw, position) != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package pagination
+
+import (
+	"context"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/query"
+)
+
+type keysetCursor struct {
+	Sort   string            `json:"s"`
+	Values map[string]string `json:"v"`
+}
+
+// After turns a cursor from a previous page into a filter for the rows that follow it,
+// the cursor is valid only with the sort it was issued for.
+func After(cursor string, sorts []query.Sort, schema query.Schema) (query.Expr, error) {
+
+	if cursor == "" {
+		return nil, nil
+	}
+
+	var position keysetCursor
+	err := DecodeCursor(cursor, &position)
+	if err != nil {
+		return nil, err
+	}
+	if position.Sort != query.FormatSort(sorts) {
+		return nil, ErrInvalidCursor
+	}
+
+	keyset, err := query.Keyset(sorts, position.Values, schema)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return keyset, nil
+}
+
+func clientSortValue(client models.Client, field string) interface{} {
+	switch field {
+	case "id":
+		return client.Id
+	case "name":
+		return client.Name
+	case "email":
+		return client.Email
+	case "status":
+		return client.Status
+	case "createdAt":
+		return client.CreatedAt
+	case "updatedAt":
+		return client.UpdatedAt
+	}
+	return nil
+}
+
+// ClientPage builds a page from up to limit+1 clients, the extra one only tells that another page exists.
+func ClientPage(clients []models.Client, limit int, sorts []query.Sort) (models.ClientPage, error) {
+
+	page := models.ClientPage{Items: clients}
+	if page.Items == nil {
+		page.Items = []models.Client{}
+	}
+	if len(clients) <= limit {
+		return page, nil
+	}
+
+	page.Items = clients[:limit]
+	last := page.Items[limit-1]
+
+	position := keysetCursor{Sort: query.FormatSort(sorts), Values: map[string]string{}}
+	for _, sort := range sorts {
+		position.Values[sort.Field] = query.FormatValue(clientSortValue(last, sort.Field))
+	}
+
+	var err error
+	page.NextCursor, err = EncodeCursor(position)
+	return page, err
+}
+
+type ClientsRequest struct {
+	Filter         string
+	Sort           string
+	After          string
+	Limit          int
+	IncludeDeleted bool
+	WithTotal      bool
+}
+
+// Clients loads one page of clients, REST and GraphQL share the same semantics through it.
+// Invalid expressions are reported as *query.Error and bad cursors as ErrInvalidCursor.
+func Clients(ctx context.Context, repo interfaces.ClientRepo, schema query.Schema, request ClientsRequest) (models.ClientPage, error) {
+
+	filter, err := query.ParseFilter(request.Filter, schema)
+	if err != nil {
+		return models.ClientPage{}, err
+	}
+	sorts, err := query.ParseSort(request.Sort, schema)
+	if err != nil {
+		return models.ClientPage{}, err
+	}
+	after, err := After(request.After, sorts, schema)
+	if err != nil {
+		return models.ClientPage{}, err
+	}
+
+	limit := Limit(request.Limit)
+	listQuery := models.ListQuery{
+		Limit:          limit + 1,
+		IncludeDeleted: request.IncludeDeleted,
+		Filter:         filter,
+		Sort:           sorts,
+	}
+	if after != nil {
+		listQuery.Filter = query.And{filter, after}
+	}
+
+	clients, err := repo.GetClients(ctx, listQuery)
+	if err != nil {
+		return models.ClientPage{}, err
+	}
+	page, err := ClientPage(clients, limit, sorts)
+	if err != nil {
+		return models.ClientPage{}, err
+	}
+
+	if request.WithTotal {
+		listQuery.Filter = filter
+		total, err := repo.CountClients(ctx, listQuery)
+		if err != nil {
+			return models.ClientPage{}, err
+		}
+		page.TotalCount = &total
+	}
+
+	return page, nil
+}
@@ -0,0 +1,39 @@
+package pagination
+
+import "testApplication/models"
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Limit clamps a requested page size, anything below one falls back to DefaultLimit.
+func Limit(requested int) int {
+	if requested < 1 {
+		return DefaultLimit
+	}
+	if requested > MaxLimit {
+		return MaxLimit
+	}
+	return requested
+}
+
+// ActivityPage builds a timeline page from up to limit+1 activities,
+// the extra one only tells that another page exists.
+func ActivityPage(activities []models.Activity, limit int) (models.ActivityPage, error) {
+
+	page := models.ActivityPage{Items: activities}
+	if page.Items == nil {
+		page.Items = []models.Activity{}
+	}
+	if len(activities) <= limit {
+		return page, nil
+	}
+
+	page.Items = activities[:limit]
+	last := page.Items[limit-1]
+
+	var err error
+	page.NextCursor, err = EncodeCursor(models.ActivityCursor{CreatedAt: last.CreatedAt, Id: last.Id})
+	return page, err
+}
@@ -0,0 +1,246 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of an RFC 6902 JSON patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply runs the operations in order on a copy of document, the first failing one aborts the patch.
+func Apply(document interface{}, operations []Operation) (interface{}, error) {
+
+	document, err := clone(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		document, err = apply(document, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return document, nil
+}
+
+func apply(document interface{}, operation Operation) (interface{}, error) {
+
+	path, err := pointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, errors.New(operation.Op + " needs a value")
+		}
+		var value interface{}
+		if err := decode(operation.Value, &value); err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case "add":
+			return add(document, path, value)
+		case "replace":
+			if document, _, err = remove(document, path); err != nil {
+				return nil, err
+			}
+			return add(document, path, value)
+		}
+		current, err := get(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed at %q", operation.Path)
+		}
+		return document, nil
+	case "remove":
+		document, _, err = remove(document, path)
+		return document, err
+	case "move", "copy":
+		from, err := pointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if operation.Op == "move" {
+			if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+				return nil, errors.New("can not move a value into itself")
+			}
+			document, value, err = remove(document, from)
+		} else {
+			value, err = get(document, from)
+			if err == nil {
+				value, err = clone(value)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	}
+	return nil, fmt.Errorf("unknown op %q", operation.Op)
+}
+
+// pointer splits an RFC 6901 JSON pointer into its unescaped reference tokens.
+func pointer(path string) ([]string, error) {
+
+	if path == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func index(token string, length int, appendable bool) (int, error) {
+
+	if appendable && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > length || (!appendable && i == length) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(document interface{}, path []string) (interface{}, error) {
+
+	for _, token := range path {
+		switch node := document.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			document = value
+		case []interface{}:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			document = node[i]
+		default:
+			return nil, fmt.Errorf("can not descend into %q", token)
+		}
+	}
+	return document, nil
+}
+
+// add sets the value at path, arrays make room for it, and returns the new document.
+func add(document interface{}, path []string, value interface{}) (interface{}, error) {
+
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return document, nil
+	case []interface{}:
+		i, err := index(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := append(node[:i:i], append([]interface{}{value}, node[i:]...)...)
+		return replaceNode(document, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("can not add to %q", last)
+}
+
+// remove deletes the value at path and returns the new document together with the removed value.
+func remove(document interface{}, path []string) (interface{}, interface{}, error) {
+
+	if len(path) == 0 {
+		return nil, nil, errors.New("can not remove the whole document")
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", last)
+		}
+		delete(node, last)
+		return document, value, nil
+	case []interface{}:
+		i, err := index(last, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[i]
+		shrunk := append(node[:i:i], node[i+1:]...)
+		document, err = replaceNode(document, path[:len(path)-1], shrunk)
+		return document, value, err
+	}
+	return nil, nil, fmt.Errorf("can not remove %q", last)
+}
+
+// replaceNode swaps the array at path for a resized one, slices are values so the parent has to be updated.
+func replaceNode(document interface{}, path []string, node interface{}) (interface{}, error) {
+
+	if len(path) == 0 {
+		return node, nil
+	}
+	parent, err := get(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[last] = node
+	case []interface{}:
+		i, err := index(last, len(parent), false)
+		if err != nil {
+			return nil, err
+		}
+		parent[i] = node
+	}
+	return document, nil
+}
+
+func clone(value interface{}) (interface{}, error) {
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied interface{}
+	err = decode(encoded, &copied)
+	return copied, err
+}
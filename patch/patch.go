@@ -0,0 +1,137 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"sort"
+)
+
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+var ErrUnsupportedMediaType = errors.New("patch documents must be " + MergePatch + " or " + JSONPatch)
+
+// Merge applies an RFC 7396 merge patch: objects are merged recursively, null removes a member
+// and every other value replaces the target one.
+func Merge(target interface{}, patch interface{}) interface{} {
+
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	merged := make(map[string]interface{}, len(targetObject))
+	for key, value := range targetObject {
+		merged[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = Merge(merged[key], value)
+	}
+	return merged
+}
+
+// Document applies the patch in body to the JSON form of current and decodes the outcome into result.
+// contentType picks merge patch, also assumed for plain application/json, or JSON patch.
+// It returns the top-level members whose value changed.
+func Document(contentType string, current interface{}, body []byte, result interface{}) ([]string, error) {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	original, err := toDocument(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched interface{}
+	switch mediaType {
+	case MergePatch, "application/json":
+		var patch interface{}
+		if err := decode(body, &patch); err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		if _, ok := patch.(map[string]interface{}); !ok {
+			return nil, errors.New("invalid merge patch: the document must be an object")
+		}
+		patched = Merge(original, patch)
+	case JSONPatch:
+		var operations []Operation
+		if err := decode(body, &operations); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+		patched, err = Apply(original, operations)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+
+	patchedObject, ok := patched.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the patched document must be an object")
+	}
+
+	encoded, err := json.Marshal(patchedObject)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(encoded, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return changed(original.(map[string]interface{}), patchedObject), nil
+}
+
+// toDocument turns a value into the generic form patches work on, numbers stay json.Number
+// so ids and integers survive the round trip.
+func toDocument(value interface{}) (interface{}, error) {
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	err = decode(encoded, &document)
+	return document, err
+}
+
+func decode(data []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
+
+func changed(original map[string]interface{}, patched map[string]interface{}) []string {
+
+	var fields []string
+	for key, value := range patched {
+		if previous, ok := original[key]; !ok || !reflect.DeepEqual(previous, value) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range original {
+		if _, ok := patched[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatSort renders sorts back into the ?sort= syntax, cursors use it to
+// notice that the order changed between pages.
+func FormatSort(sorts []Sort) string {
+
+	parts := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		if sort.Desc {
+			parts = append(parts, "-"+sort.Field)
+		} else {
+			parts = append(parts, sort.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// FormatValue renders a sort key value so Keyset can parse it back with its field type.
+func FormatValue(value interface{}) string {
+	if value, ok := value.(time.Time); ok {
+		return value.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// Keyset matches the rows that come after the row with the given sort key values,
+// e.g. for -createdAt,id: createdAt < v1 OR (createdAt = v1 AND id > v2).
+func Keyset(sorts []Sort, values map[string]string, schema Schema) (Expr, error) {
+
+	var (
+		keyset Or
+		equal  And
+	)
+
+	for _, sort := range sorts {
+		name, field, ok := schema.lookup(sort.Field)
+		if !ok || !field.Sortable {
+			return nil, &Error{sort.Field, "field can not be sorted"}
+		}
+		raw, ok := values[name]
+		if !ok {
+			return nil, &Error{sort.Field, "cursor has no value for the field"}
+		}
+		value, err := parseValue(field, raw)
+		if err != nil {
+			return nil, &Error{sort.Field, err.Error()}
+		}
+
+		op := Gt
+		if sort.Desc {
+			op = Lt
+		}
+
+		step := append(And{}, equal...)
+		step = append(step, Condition{Field: name, Type: field.Type, Op: op, Value: value})
+		keyset = append(keyset, step)
+
+		equal = append(equal, Condition{Field: name, Type: field.Type, Op: Eq, Value: value})
+	}
+
+	return keyset, nil
+}
@@ -0,0 +1,217 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error describes an invalid filter or sort expression.
+type Error struct {
+	Expression string
+	Message    string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("invalid expression %q: %s", err.Expression, err.Message)
+}
+
+var operators = []Op{Ne, Gte, Lte, Eq, Gt, Lt, Contains}
+
+// splitEscaped splits on sep unless it is escaped with a backslash.
+func splitEscaped(s string, sep byte) []string {
+	var (
+		parts   []string
+		current strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			current.WriteByte(s[i])
+			continue
+		}
+		if s[i] == sep {
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(s[i])
+	}
+	return append(parts, current.String())
+}
+
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// ParseFilter parses comma separated conditions which all have to match, e.g.
+// name~acme,status=lead|active,createdAt>=2023-01-01. "|" lists alternatives for "=".
+func ParseFilter(filter string, schema Schema) (Expr, error) {
+
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	var conditions And
+	for _, raw := range splitEscaped(filter, ',') {
+		condition, err := parseCondition(raw, schema)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+func parseCondition(raw string, schema Schema) (Condition, error) {
+
+	end := 0
+	for end < len(raw) && isFieldChar(raw[end]) {
+		end++
+	}
+	if end == 0 {
+		return Condition{}, &Error{raw, "expected a field name"}
+	}
+
+	var op Op
+	for _, candidate := range operators {
+		if strings.HasPrefix(raw[end:], string(candidate)) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return Condition{}, &Error{raw, "expected one of the operators = != > >= < <= ~"}
+	}
+	rawValue := raw[end+len(op):]
+
+	name, field, ok := schema.lookup(raw[:end])
+	if !ok || !field.Filterable {
+		return Condition{}, &Error{raw, "field " + raw[:end] + " can not be filtered"}
+	}
+	if err := checkOperator(field.Type, op); err != nil {
+		return Condition{}, &Error{raw, err.Error()}
+	}
+
+	condition := Condition{Field: name, Type: field.Type, Op: op}
+
+	if op == Eq && strings.Contains(rawValue, "|") {
+		var values []interface{}
+		for _, alternative := range strings.Split(rawValue, "|") {
+			value, err := parseValue(field, alternative)
+			if err != nil {
+				return Condition{}, &Error{raw, err.Error()}
+			}
+			values = append(values, value)
+		}
+		condition.Op = In
+		condition.Value = values
+		return condition, nil
+	}
+
+	value, err := parseValue(field, rawValue)
+	if err != nil {
+		return Condition{}, &Error{raw, err.Error()}
+	}
+	condition.Value = value
+
+	return condition, nil
+}
+
+func checkOperator(fieldType FieldType, op Op) error {
+	switch {
+	case op == Contains && fieldType != String:
+		return fmt.Errorf("~ works only on text fields")
+	case fieldType == StringList && op != Eq && op != Ne:
+		return fmt.Errorf("list fields support only = and !=")
+	case fieldType == Bool && op != Eq && op != Ne:
+		return fmt.Errorf("boolean fields support only = and !=")
+	}
+	return nil
+}
+
+func parseValue(field Field, raw string) (interface{}, error) {
+	switch field.Type {
+	case Integer:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return value, nil
+	case Number:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return value, nil
+	case Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return value, nil
+	case Time:
+		if value, err := time.Parse(time.RFC3339, raw); err == nil {
+			return value, nil
+		}
+		value, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a RFC 3339 time or a YYYY-MM-DD date", raw)
+		}
+		return value, nil
+	case Date:
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, fmt.Errorf("%q is not a YYYY-MM-DD date", raw)
+		}
+	case Enum:
+		for _, value := range field.Values {
+			if raw == value {
+				return raw, nil
+			}
+		}
+		if len(field.Values) > 0 {
+			return nil, fmt.Errorf("%q is not one of %s", raw, strings.Join(field.Values, ", "))
+		}
+	}
+	return raw, nil
+}
+
+// ParseSort parses comma separated fields, a leading "-" sorts descending, e.g. -createdAt,name.
+// Rows are always ordered by id last so the order is stable.
+func ParseSort(sort string, schema Schema) ([]Sort, error) {
+
+	var sorts []Sort
+	hasId := false
+
+	for _, raw := range strings.Split(sort, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		item := Sort{}
+		if name, found := strings.CutPrefix(raw, "-"); found {
+			item.Desc = true
+			raw = name
+		} else {
+			raw = strings.TrimPrefix(raw, "+")
+		}
+
+		name, field, ok := schema.lookup(raw)
+		if !ok || !field.Sortable {
+			return nil, &Error{raw, "field " + raw + " can not be sorted"}
+		}
+		item.Field = name
+		hasId = hasId || name == "id"
+
+		sorts = append(sorts, item)
+	}
+
+	if !hasId {
+		sorts = append(sorts, Sort{Field: "id"})
+	}
+
+	return sorts, nil
+}
@@ -0,0 +1,44 @@
+package query
+
+// Expr is a node of a backend-neutral filter, repositories translate it
+// into their own query language.
+type Expr interface {
+	isExpr()
+}
+
+// And matches when every expression matches.
+type And []Expr
+
+// Or matches when any expression matches.
+type Or []Expr
+
+type Op string
+
+const (
+	Eq       Op = "="
+	Ne       Op = "!="
+	Gt       Op = ">"
+	Gte      Op = ">="
+	Lt       Op = "<"
+	Lte      Op = "<="
+	Contains Op = "~"
+	In       Op = "in"
+)
+
+// Condition compares a field with a value already converted to the field type,
+// for In the value is a []interface{}.
+type Condition struct {
+	Field string
+	Type  FieldType
+	Op    Op
+	Value interface{}
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+func (And) isExpr()       {}
+func (Or) isExpr()        {}
+func (Condition) isExpr() {}
@@ -0,0 +1,90 @@
+package query
+
+import "strings"
+
+type FieldType string
+
+const (
+	String     FieldType = "string"
+	Integer    FieldType = "integer"
+	Number     FieldType = "number"
+	Bool       FieldType = "bool"
+	Time       FieldType = "time"
+	Date       FieldType = "date"
+	Enum       FieldType = "enum"
+	StringList FieldType = "list"
+)
+
+type Field struct {
+	Type       FieldType
+	Filterable bool
+	Sortable   bool
+	// Values are the allowed values of an Enum field.
+	Values []string
+}
+
+// Schema is the whitelist of fields a resource may be filtered and sorted by.
+type Schema struct {
+	Fields map[string]Field
+	// Dynamic holds filterable fields under a prefix, e.g. client custom fields.
+	Dynamic map[string]map[string]Field
+}
+
+// CustomFieldsPrefix addresses client custom fields in filters, e.g. customFields.region=eu.
+const CustomFieldsPrefix = "customFields."
+
+// normalize accepts snake_case names, created_at and createdAt address the same field.
+func normalize(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func (schema Schema) lookup(name string) (string, Field, bool) {
+
+	for prefix, fields := range schema.Dynamic {
+		if dynamicName, found := strings.CutPrefix(name, prefix); found {
+			field, ok := fields[dynamicName]
+			field.Filterable = true
+			return name, field, ok
+		}
+	}
+
+	name = normalize(name)
+	field, ok := schema.Fields[name]
+	return name, field, ok
+}
+
+// ClientSchema takes the allowed statuses from the caller, models depends on this package.
+func ClientSchema(statuses []string, customFields map[string]Field) Schema {
+	return Schema{
+		Fields: map[string]Field{
+			"id":        {Type: Integer, Filterable: true, Sortable: true},
+			"name":      {Type: String, Filterable: true, Sortable: true},
+			"email":     {Type: String, Filterable: true, Sortable: true},
+			"phone":     {Type: String, Filterable: true},
+			"taxId":     {Type: String, Filterable: true},
+			"status":    {Type: Enum, Filterable: true, Sortable: true, Values: statuses},
+			"tags":      {Type: StringList, Filterable: true},
+			"createdAt": {Type: Time, Filterable: true, Sortable: true},
+			"updatedAt": {Type: Time, Filterable: true, Sortable: true},
+		},
+		Dynamic: map[string]map[string]Field{
+			CustomFieldsPrefix: customFields,
+		},
+	}
+}
+
+func UserSchema() Schema {
+	return Schema{
+		Fields: map[string]Field{
+			"id":    {Type: Integer, Filterable: true, Sortable: true},
+			"name":  {Type: String, Filterable: true, Sortable: true},
+			"email": {Type: String, Filterable: true, Sortable: true},
+		},
+	}
+}
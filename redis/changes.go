@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Changes are kept in a capped stream, which gives them ordered ids to resume from, and announced
+// on a channel so every instance hears about them as they happen.
+const (
+	changesStream  = "changes"
+	changesChannel = "changes"
+	changesKept    = 10000
+)
+
+// Change is a published change with the stream id it was stored under.
+type Change struct {
+	Id      string
+	Payload []byte
+}
+
+// PublishChange stores the payload in the change stream and announces it to all subscribed instances.
+func (redisConn *Connection) PublishChange(ctx context.Context, payload []byte) (string, error) {
+
+	id, err := redisConn.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: changesStream,
+		MaxLen: changesKept,
+		Approx: true,
+		Values: map[string]interface{}{"payload": payload},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+
+	// the id goes first, the payload follows the first space
+	err = redisConn.client.Publish(ctx, changesChannel, id+" "+string(payload)).Err()
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// SubscribeChanges delivers the announced changes until ctx is cancelled, the channel is closed then.
+func (redisConn *Connection) SubscribeChanges(ctx context.Context) (<-chan Change, error) {
+
+	pubsub := redisConn.client.Subscribe(ctx, changesChannel)
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	changes := make(chan Change)
+	go func() {
+		defer close(changes)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				change, ok := parseChange(message.Payload)
+				if !ok {
+					log.Printf("redis: malformed change message %q", message.Payload)
+					continue
+				}
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+func parseChange(message string) (Change, bool) {
+	id, payload, ok := strings.Cut(message, " ")
+	return Change{Id: id, Payload: []byte(payload)}, ok
+}
+
+// ParseChangeId splits a stream id like 1700000000000-0 into its milliseconds and sequence.
+func ParseChangeId(id string) (ms uint64, seq uint64, ok bool) {
+
+	msPart, seqPart, found := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !found {
+		return ms, 0, true
+	}
+	seq, err = strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq, err == nil
+}
+
+// ChangeIdLess orders two valid change ids.
+func ChangeIdLess(a string, b string) bool {
+	aMs, aSeq, _ := ParseChangeId(a)
+	bMs, bSeq, _ := ParseChangeId(b)
+	return aMs < bMs || aMs == bMs && aSeq < bSeq
+}
+
+// ChangesAfter returns the stored changes following the one with id, oldest first. Only the latest
+// changes are kept, complete is false when id is older than all of them and some may be missing.
+func (redisConn *Connection) ChangesAfter(ctx context.Context, id string) (changes []Change, complete bool, err error) {
+
+	messages, err := redisConn.client.XRange(ctx, changesStream, "("+id, "+").Result()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, message := range messages {
+		payload, _ := message.Values["payload"].(string)
+		changes = append(changes, Change{Id: message.ID, Payload: []byte(payload)})
+	}
+
+	first, err := redisConn.client.XRangeN(ctx, changesStream, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	complete = len(first) == 0 || !ChangeIdLess(id, first[0].ID)
+	return changes, complete, nil
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyMismatch   = errors.New("the idempotency key was already used for a different request")
+)
+
+// IdempotentResponse is the stored outcome of the first request sent with an idempotency key.
+type IdempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+type idempotencyRecord struct {
+	RequestHash string              `json:"requestHash"`
+	Response    *IdempotentResponse `json:"response,omitempty"`
+}
+
+// idempotencyKey namespaces key by scope, the caller the key belongs to.
+func idempotencyKey(scope string, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", scope, key)
+}
+
+// StartIdempotent claims key for the request hashed as requestHash for at most lockTimeout.
+// It returns nil, nil when the request has to run, the stored response when it already did and
+// ErrIdempotencyInProgress or ErrIdempotencyMismatch when the key is taken.
+func (redisConn *Connection) StartIdempotent(ctx context.Context, scope string, key string, requestHash string, lockTimeout time.Duration) (*IdempotentResponse, error) {
+
+	pending, err := json.Marshal(idempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := redisConn.client.SetNX(ctx, idempotencyKey(scope, key), pending, lockTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	stored, err := redisConn.client.Get(ctx, idempotencyKey(scope, key)).Bytes()
+	if err != nil {
+		// the record expired in between, the caller may retry
+		return nil, ErrIdempotencyInProgress
+	}
+	var record idempotencyRecord
+	err = json.Unmarshal(stored, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyMismatch
+	}
+	if record.Response == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return record.Response, nil
+}
+
+// FinishIdempotent stores the response of a claimed key so replays within window get it back.
+func (redisConn *Connection) FinishIdempotent(ctx context.Context, scope string, key string, requestHash string, response IdempotentResponse, window time.Duration) error {
+
+	done, err := json.Marshal(idempotencyRecord{RequestHash: requestHash, Response: &response})
+	if err != nil {
+		return err
+	}
+	return redisConn.client.Set(ctx, idempotencyKey(scope, key), done, window).Err()
+}
+
+// ReleaseIdempotent frees a claimed key without a stored response, a retry runs the request again.
+func (redisConn *Connection) ReleaseIdempotent(ctx context.Context, scope string, key string) error {
+	return redisConn.client.Del(ctx, idempotencyKey(scope, key)).Err()
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"testApplication/models"
+	"time"
+)
+
+func importJobKey(id string) string {
+	return "import:" + id
+}
+
+// SaveImportJob stores a snapshot of the job for ttl, so every instance can answer for it.
+func (redisConn *Connection) SaveImportJob(ctx context.Context, job models.ImportJob, ttl time.Duration) error {
+
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return redisConn.client.Set(ctx, importJobKey(job.Id), encoded, ttl).Err()
+}
+
+// GetImportJob returns the last stored snapshot of a job, false when there is none.
+func (redisConn *Connection) GetImportJob(ctx context.Context, id string) (models.ImportJob, bool, error) {
+
+	stored, err := redisConn.client.Get(ctx, importJobKey(id)).Bytes()
+	if err == redis.Nil {
+		return models.ImportJob{}, false, nil
+	}
+	if err != nil {
+		return models.ImportJob{}, false, err
+	}
+
+	var job models.ImportJob
+	err = json.Unmarshal(stored, &job)
+	if err != nil {
+		return models.ImportJob{}, false, err
+	}
+	return job, true, nil
+}
@@ -0,0 +1,141 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+func (m mongodb) GetActivities(ctx context.Context, clientId int, after models.ActivityCursor, limit int) ([]models.Activity, error) {
+
+	var activities []models.Activity
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}}, false)
+	if after.Id != 0 {
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "createdAt", Value: bson.D{{Key: "$lt", Value: after.CreatedAt}}}},
+			bson.D{{Key: "createdAt", Value: after.CreatedAt}, {Key: "id", Value: bson.D{{Key: "$lt", Value: after.Id}}}},
+		}})
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := m.activitiesCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return activities, err
+	}
+
+	err = cursor.All(ctx, &activities)
+	if err != nil {
+		log.Println(err)
+		return activities, err
+	}
+
+	return activities, nil
+}
+
+func (m mongodb) GetActivityById(ctx context.Context, clientId int, id int) (models.Activity, error) {
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}, false)
+
+	var activity models.Activity
+	err := m.activitiesCollection.FindOne(ctx, filter).Decode(&activity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Activity{}, apperrors.NotFound("No activity found by id %d", id)
+		}
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return activity, nil
+}
+
+func (m mongodb) CreateActivity(ctx context.Context, activity models.Activity) (models.Activity, error) {
+
+	count, err := m.clientsCollection.CountDocuments(ctx, notDeletedFilter(bson.D{{Key: "id", Value: activity.ClientId}}, false))
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+	if count == 0 {
+		return models.Activity{}, apperrors.NotFound("No client found by id %d", activity.ClientId)
+	}
+
+	activity.Id, err = nextId(ctx, m.activitiesCollection)
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+	// mongo keeps milliseconds, truncate so cursors built from the returned value match stored documents
+	activity.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	activity.UpdatedAt = activity.CreatedAt
+
+	_, err = m.activitiesCollection.InsertOne(ctx, activity)
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return activity, nil
+}
+
+func (m mongodb) UpdateActivity(ctx context.Context, activity models.Activity) (models.Activity, error) {
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: activity.ClientId}, {Key: "id", Value: activity.Id}}, false)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "kind", Value: activity.Kind},
+		{Key: "body", Value: activity.Body},
+		{Key: "updatedAt", Value: time.Now().UTC().Truncate(time.Millisecond)},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Activity
+	err := m.activitiesCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Activity{}, apperrors.NotFound("No activity found by id %d", activity.Id)
+		}
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return updated, nil
+}
+
+func (m mongodb) DeleteActivity(ctx context.Context, clientId int, id int) error {
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}, false)
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: time.Now().UTC()}}}}
+
+	updateResult, err := m.activitiesCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if updateResult.MatchedCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
+
+func (m mongodb) PurgeDeletedActivities(ctx context.Context, deletedBefore time.Time) (int64, error) {
+
+	filter := bson.D{{Key: "deletedAt", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}}
+
+	deleteResult, err := m.activitiesCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
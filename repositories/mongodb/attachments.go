@@ -0,0 +1,120 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+func (m mongodb) GetAttachments(ctx context.Context, clientId int) ([]models.Attachment, error) {
+
+	var attachments []models.Attachment
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}}, false)
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+
+	cursor, err := m.attachmentsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return attachments, err
+	}
+
+	err = cursor.All(ctx, &attachments)
+	if err != nil {
+		log.Println(err)
+		return attachments, err
+	}
+
+	return attachments, nil
+}
+
+func (m mongodb) GetAttachmentById(ctx context.Context, clientId int, id int) (models.Attachment, error) {
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}, false)
+
+	var attachment models.Attachment
+	err := m.attachmentsCollection.FindOne(ctx, filter).Decode(&attachment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Attachment{}, apperrors.NotFound("No attachment found by id %d", id)
+		}
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (m mongodb) CreateAttachment(ctx context.Context, attachment models.Attachment) (models.Attachment, error) {
+
+	count, err := m.clientsCollection.CountDocuments(ctx, notDeletedFilter(bson.D{{Key: "id", Value: attachment.ClientId}}, false))
+	if err != nil {
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+	if count == 0 {
+		return models.Attachment{}, apperrors.NotFound("No client found by id %d", attachment.ClientId)
+	}
+
+	attachment.Id, err = nextId(ctx, m.attachmentsCollection)
+	if err != nil {
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+	attachment.CreatedAt = time.Now().UTC()
+
+	_, err = m.attachmentsCollection.InsertOne(ctx, attachment)
+	if err != nil {
+		log.Println(err)
+		return models.Attachment{}, dbError(err)
+	}
+
+	return attachment, nil
+}
+
+func (m mongodb) DeleteAttachment(ctx context.Context, clientId int, id int) error {
+
+	filter := bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}
+
+	deleteResult, err := m.attachmentsCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
+
+func (m mongodb) PurgeDeletedAttachments(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+
+	var keys []string
+
+	filter := bson.D{{Key: "deletedAt", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}}
+
+	values, err := m.attachmentsCollection.Distinct(ctx, "storageKey", filter)
+	if err != nil {
+		log.Println(err)
+		return keys, err
+	}
+	for _, value := range values {
+		if key, ok := value.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	_, err = m.attachmentsCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return keys, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,85 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+// runClientOperation applies one batch operation, within a transaction when ctx is a session context.
+func (m mongodb) runClientOperation(ctx context.Context, operation models.ClientOperation) (models.ClientOperationResult, error) {
+
+	result := models.ClientOperationResult{Op: operation.Op, Id: operation.Id}
+
+	var err error
+	switch operation.Op {
+	case models.ClientOpCreate:
+		var client models.Client
+		client, err = m.CreateClient(ctx, operation.Client.WithDefaults())
+		if err == nil {
+			result.Id, result.Client = client.Id, &client
+		}
+	case models.ClientOpUpdate:
+		operation.Client.Id = operation.Id
+		err = m.UpdateClient(ctx, operation.Client)
+		if err == nil {
+			var client models.Client
+			client, err = m.GetClientById(ctx, operation.Id, false)
+			result.Client = &client
+		}
+	case models.ClientOpDelete:
+		err = m.DeleteClient(ctx, operation.Id, operation.Version)
+	default:
+		err = apperrors.BadRequest("unknown operation %s", operation.Op)
+	}
+
+	if err != nil {
+		result.Client = nil
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+func (m mongodb) BatchClients(ctx context.Context, operations []models.ClientOperation, atomic bool) ([]models.ClientOperationResult, error) {
+
+	var results []models.ClientOperationResult
+
+	if !atomic {
+		for i, operation := range operations {
+			result, _ := m.runClientOperation(ctx, operation)
+			result.Index = i
+			results = append(results, result)
+		}
+		return results, nil
+	}
+
+	// multi-document transactions need mongo to run as a replica set
+	session, err := m.client.StartSession()
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+
+		// WithTransaction retries transient failures, every attempt reports from scratch
+		results = results[:0]
+		for i, operation := range operations {
+			result, err := m.runClientOperation(sessionContext, operation)
+			result.Index = i
+			results = append(results, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	return results, err
+}
@@ -0,0 +1,142 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+func (m mongodb) GetContacts(ctx context.Context, clientId int) ([]models.Contact, error) {
+
+	var contacts []models.Contact
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}}, false)
+	opts := options.Find().SetSort(bson.D{{Key: "primary", Value: -1}, {Key: "id", Value: 1}})
+
+	cursor, err := m.contactsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return contacts, err
+	}
+
+	err = cursor.All(ctx, &contacts)
+	if err != nil {
+		log.Println(err)
+		return contacts, err
+	}
+
+	return contacts, nil
+}
+
+func (m mongodb) GetContactById(ctx context.Context, clientId int, id int) (models.Contact, error) {
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}, false)
+
+	var contact models.Contact
+	err := m.contactsCollection.FindOne(ctx, filter).Decode(&contact)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Contact{}, apperrors.NotFound("No contact found by id %d", id)
+		}
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	return contact, nil
+}
+
+// resetPrimaryContact clears the primary flag of every other contact of the client,
+// a client has at most one primary contact.
+func (m mongodb) resetPrimaryContact(ctx context.Context, contact models.Contact) error {
+
+	if !contact.Primary {
+		return nil
+	}
+
+	filter := bson.D{{Key: "clientId", Value: contact.ClientId}, {Key: "id", Value: bson.D{{Key: "$ne", Value: contact.Id}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "primary", Value: false}}}}
+
+	_, err := m.contactsCollection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (m mongodb) CreateContact(ctx context.Context, contact models.Contact) (models.Contact, error) {
+
+	count, err := m.clientsCollection.CountDocuments(ctx, notDeletedFilter(bson.D{{Key: "id", Value: contact.ClientId}}, false))
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+	if count == 0 {
+		return models.Contact{}, apperrors.NotFound("No client found by id %d", contact.ClientId)
+	}
+
+	contact.Id, err = nextId(ctx, m.contactsCollection)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	err = m.resetPrimaryContact(ctx, contact)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	_, err = m.contactsCollection.InsertOne(ctx, contact)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, dbError(err)
+	}
+
+	return contact, nil
+}
+
+func (m mongodb) UpdateContact(ctx context.Context, contact models.Contact) (models.Contact, error) {
+
+	err := m.resetPrimaryContact(ctx, contact)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	filter := notDeletedFilter(bson.D{{Key: "clientId", Value: contact.ClientId}, {Key: "id", Value: contact.Id}}, false)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "name", Value: contact.Name},
+		{Key: "email", Value: contact.Email},
+		{Key: "phone", Value: contact.Phone},
+		{Key: "position", Value: contact.Position},
+		{Key: "primary", Value: contact.Primary},
+	}}}
+
+	updateResult, err := m.contactsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+	if updateResult.MatchedCount == 0 {
+		return models.Contact{}, apperrors.NotFound("No contact found by id %d", contact.Id)
+	}
+
+	return contact, nil
+}
+
+func (m mongodb) DeleteContact(ctx context.Context, clientId int, id int) error {
+
+	filter := bson.D{{Key: "clientId", Value: clientId}, {Key: "id", Value: id}}
+
+	deleteResult, err := m.contactsCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
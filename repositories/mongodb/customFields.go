@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+func (m mongodb) GetCustomFields(ctx context.Context) ([]models.CustomField, error) {
+
+	var fields []models.CustomField
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+
+	cursor, err := m.customFieldsCollection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		log.Println(err)
+		return fields, err
+	}
+
+	err = cursor.All(ctx, &fields)
+	if err != nil {
+		log.Println(err)
+		return fields, err
+	}
+
+	return fields, nil
+}
+
+func (m mongodb) CreateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error) {
+
+	count, err := m.customFieldsCollection.CountDocuments(ctx, bson.D{{Key: "name", Value: field.Name}})
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+	if count > 0 {
+		return models.CustomField{}, apperrors.Conflict("Custom field %s already exists", field.Name)
+	}
+
+	field.Id, err = nextId(ctx, m.customFieldsCollection)
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+
+	_, err = m.customFieldsCollection.InsertOne(ctx, field)
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, dbError(err)
+	}
+
+	return field, nil
+}
+
+func (m mongodb) UpdateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error) {
+
+	filter := bson.D{{Key: "id", Value: field.Id}}
+
+	updateResult, err := m.customFieldsCollection.ReplaceOne(ctx, filter, field)
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+	if updateResult.MatchedCount == 0 {
+		return models.CustomField{}, apperrors.NotFound("No custom field found by id %d", field.Id)
+	}
+
+	return field, nil
+}
+
+func (m mongodb) DeleteCustomField(ctx context.Context, id int) error {
+
+	filter := bson.D{{Key: "id", Value: id}}
+
+	deleteResult, err := m.customFieldsCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
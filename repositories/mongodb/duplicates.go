@@ -0,0 +1,174 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"testApplication/search"
+	"time"
+)
+
+// looseTaxId matches a normalized tax id however it is spaced, dashed or cased in a document.
+func looseTaxId(taxId string) string {
+
+	var chars []string
+	for _, char := range taxId {
+		chars = append(chars, regexp.QuoteMeta(string(char)))
+	}
+	return `^\W*` + strings.Join(chars, `\W*`) + `\W*$`
+}
+
+func (m mongodb) DuplicateCandidates(ctx context.Context, query models.DuplicateQuery, limit int) ([]models.Client, error) {
+
+	var clients []models.Client
+
+	var conditions bson.A
+	if query.TaxId != "" {
+		conditions = append(conditions, bson.D{{Key: "taxId", Value: bson.D{
+			{Key: "$regex", Value: looseTaxId(query.TaxId)},
+			{Key: "$options", Value: "i"},
+		}}})
+	}
+	if query.EmailDomain != "" {
+		conditions = append(conditions, bson.D{{Key: "email", Value: bson.D{
+			{Key: "$regex", Value: "@" + regexp.QuoteMeta(query.EmailDomain) + "$"},
+			{Key: "$options", Value: "i"},
+		}}})
+	}
+	if fragments := search.Fragments(query.NameTerms); len(fragments) > 0 {
+		for i, fragment := range fragments {
+			fragments[i] = regexp.QuoteMeta(fragment)
+		}
+		conditions = append(conditions, bson.D{{Key: "name", Value: bson.D{
+			{Key: "$regex", Value: strings.Join(fragments, "|")},
+			{Key: "$options", Value: "i"},
+		}}})
+	}
+	if len(conditions) == 0 {
+		return clients, nil
+	}
+
+	filter := notDeletedFilter(bson.D{
+		{Key: "id", Value: bson.D{{Key: "$ne", Value: query.ExcludeId}}},
+		{Key: "$or", Value: conditions},
+	}, false)
+
+	cursor, err := m.clientsCollection.Find(ctx, filter, options.Find().SetLimit(fuzzyCandidates))
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+	err = cursor.All(ctx, &clients)
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+
+	// names sharing a single trigram are a weak hint, keep the most similar ones like postgres does
+	sort.SliceStable(clients, func(i, j int) bool {
+		return search.Rank(query.NameTerms, clients[i].Name) > search.Rank(query.NameTerms, clients[j].Name)
+	})
+	if len(clients) > limit {
+		clients = clients[:limit]
+	}
+
+	return clients, nil
+}
+
+func (m mongodb) MergeClients(ctx context.Context, target models.Client, sourceId int, mergedBy int) (models.ClientMerge, error) {
+
+	var source models.Client
+	err := m.clientsCollection.FindOne(ctx, notDeletedFilter(bson.D{{Key: "id", Value: sourceId}}, false)).Decode(&source)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.ClientMerge{}, apperrors.NotFound("No client found by id %d", sourceId)
+		}
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	err = m.UpdateClient(ctx, target)
+	if err != nil {
+		return models.ClientMerge{}, err
+	}
+
+	// a client has one primary contact, the target one stays primary
+	targetPrimary, err := m.contactsCollection.CountDocuments(ctx, notDeletedFilter(bson.D{{Key: "clientId", Value: target.Id}, {Key: "primary", Value: true}}, false))
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+	if targetPrimary > 0 {
+		_, err = m.contactsCollection.UpdateMany(ctx,
+			bson.D{{Key: "clientId", Value: sourceId}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "primary", Value: false}}}},
+		)
+		if err != nil {
+			log.Println(err)
+			return models.ClientMerge{}, err
+		}
+	}
+
+	repoint := bson.D{{Key: "$set", Value: bson.D{{Key: "clientId", Value: target.Id}}}}
+	for _, collection := range m.clientDependents() {
+		_, err = collection.UpdateMany(ctx, bson.D{{Key: "clientId", Value: sourceId}}, repoint)
+		if err != nil {
+			log.Println(err)
+			return models.ClientMerge{}, err
+		}
+	}
+
+	mergedAt := time.Now().UTC()
+	_, err = m.clientsCollection.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: sourceId}},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: mergedAt}}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		},
+	)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	merge := models.ClientMerge{TargetId: target.Id, SourceId: sourceId, MergedBy: mergedBy, MergedAt: mergedAt, Source: source}
+	merge.Id, err = nextId(ctx, m.clientMergesCollection)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+	_, err = m.clientMergesCollection.InsertOne(ctx, merge)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	return merge, nil
+}
+
+func (m mongodb) GetClientMerges(ctx context.Context, targetId int) ([]models.ClientMerge, error) {
+
+	merges := []models.ClientMerge{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "mergedAt", Value: -1}, {Key: "id", Value: -1}})
+	cursor, err := m.clientMergesCollection.Find(ctx, bson.D{{Key: "targetId", Value: targetId}}, opts)
+	if err != nil {
+		log.Println(err)
+		return merges, err
+	}
+
+	err = cursor.All(ctx, &merges)
+	if err != nil {
+		log.Println(err)
+		return merges, err
+	}
+
+	return merges, nil
+}
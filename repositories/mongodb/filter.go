@@ -0,0 +1,114 @@
+package mongodb
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"regexp"
+	"strings"
+	"testApplication/query"
+)
+
+// clientKeysByField maps filterable fields to document keys, custom fields keep their dotted path.
+var clientKeysByField = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"email":     "email",
+	"phone":     "phone",
+	"taxId":     "taxId",
+	"status":    "status",
+	"tags":      "tags",
+	"createdAt": "createdAt",
+	"updatedAt": "updatedAt",
+}
+
+func documentKey(field string, keys map[string]string) (string, error) {
+	if key, ok := keys[field]; ok {
+		return key, nil
+	}
+	if strings.HasPrefix(field, query.CustomFieldsPrefix) {
+		return field, nil
+	}
+	return "", fmt.Errorf("field %s is not mapped to a document key", field)
+}
+
+var mongoOperators = map[query.Op]string{
+	query.Ne:  "$ne",
+	query.Gt:  "$gt",
+	query.Gte: "$gte",
+	query.Lt:  "$lt",
+	query.Lte: "$lte",
+	query.In:  "$in",
+}
+
+// toBson translates a filter expression into a mongo query document.
+func toBson(expr query.Expr, keys map[string]string) (bson.D, error) {
+
+	switch expr := expr.(type) {
+	case nil:
+		return bson.D{}, nil
+	case query.And:
+		return joinBson("$and", expr, keys)
+	case query.Or:
+		return joinBson("$or", expr, keys)
+	case query.Condition:
+		key, err := documentKey(expr.Field, keys)
+		if err != nil {
+			return nil, err
+		}
+		switch expr.Op {
+		case query.Eq:
+			return bson.D{{Key: key, Value: expr.Value}}, nil
+		case query.Contains:
+			pattern := regexp.QuoteMeta(fmt.Sprint(expr.Value))
+			return bson.D{{Key: key, Value: bson.D{{Key: "$regex", Value: pattern}, {Key: "$options", Value: "i"}}}}, nil
+		}
+		op, ok := mongoOperators[expr.Op]
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator %s", expr.Op)
+		}
+		value := expr.Value
+		if values, ok := value.([]interface{}); ok {
+			value = bson.A(values)
+		}
+		return bson.D{{Key: key, Value: bson.D{{Key: op, Value: value}}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported filter expression %T", expr)
+}
+
+func joinBson(op string, exprs []query.Expr, keys map[string]string) (bson.D, error) {
+
+	if len(exprs) == 0 {
+		return bson.D{}, nil
+	}
+
+	parts := bson.A{}
+	for _, expr := range exprs {
+		part, err := toBson(expr, keys)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return bson.D{{Key: op, Value: parts}}, nil
+}
+
+func sortBson(sorts []query.Sort, keys map[string]string) (bson.D, error) {
+
+	if len(sorts) == 0 {
+		return bson.D{{Key: "id", Value: 1}}, nil
+	}
+
+	sort := bson.D{}
+	for _, item := range sorts {
+		key, err := documentKey(item.Field, keys)
+		if err != nil {
+			return nil, err
+		}
+		direction := 1
+		if item.Desc {
+			direction = -1
+		}
+		sort = append(sort, bson.E{Key: key, Value: direction})
+	}
+	return sort, nil
+}
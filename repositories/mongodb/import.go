@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"testApplication/models"
+	"time"
+)
+
+// importBatch is how many documents one InsertMany sends.
+const importBatch = 1000
+
+func (m mongodb) ImportClients(ctx context.Context, clients []models.Client, progress func(imported int)) ([]models.Client, error) {
+
+	firstId, err := reserveIds(ctx, m.clientsCollection, len(clients))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	imported := make([]models.Client, 0, len(clients))
+	for i, client := range clients {
+		client = client.WithDefaults()
+		client.Id = firstId + i
+		client.CreatedAt = now
+		client.UpdatedAt = now
+		client.Version = 1
+		imported = append(imported, client)
+	}
+
+	// multi-document transactions need mongo to run as a replica set
+	session, err := m.client.StartSession()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+
+		// WithTransaction retries transient failures, every attempt inserts and reports from scratch
+		for start := 0; start < len(imported); start += importBatch {
+
+			end := start + importBatch
+			if end > len(imported) {
+				end = len(imported)
+			}
+
+			documents := make([]interface{}, 0, end-start)
+			for _, client := range imported[start:end] {
+				documents = append(documents, client)
+			}
+
+			_, err := m.clientsCollection.InsertMany(sessionContext, documents)
+			if err != nil {
+				return nil, err
+			}
+			progress(end)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return imported, nil
+}
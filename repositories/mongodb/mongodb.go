@@ -2,20 +2,30 @@ package mongodb
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	migrateMongo "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
 	"testApplication/models"
 	"testApplication/utils"
+	"time"
 )
 
 type mongodb struct {
-	client            *mongo.Client
-	database          *mongo.Database
-	clientsCollection *mongo.Collection
+	client                 *mongo.Client
+	database               *mongo.Database
+	clientsCollection      *mongo.Collection
+	customFieldsCollection *mongo.Collection
+	contactsCollection     *mongo.Collection
+	activitiesCollection   *mongo.Collection
+	attachmentsCollection  *mongo.Collection
+	clientMergesCollection *mongo.Collection
 }
 
 func InitConnection() *mongodb {
@@ -34,19 +44,86 @@ func InitConnection() *mongodb {
 		log.Fatal(err)
 	}
 
-	return &mongodb{client: client, database: mongoDatabase, clientsCollection: mongoClientsCollection}
+	driver, err := migrateMongo.WithInstance(client, &migrateMongo.Config{DatabaseName: database})
+	if err != nil {
+		log.Fatal(err)
+	}
+	migr, err := migrate.NewWithDatabaseInstance("file://db/mongodb-migrations", database, driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = migr.Up()
+	if err != nil {
+		if err != migrate.ErrNoChange {
+			log.Fatal(err)
+		}
+	}
+
+	return &mongodb{
+		client:                 client,
+		database:               mongoDatabase,
+		clientsCollection:      mongoClientsCollection,
+		customFieldsCollection: mongoDatabase.Collection("customFields"),
+		contactsCollection:     mongoDatabase.Collection("contacts"),
+		activitiesCollection:   mongoDatabase.Collection("activities"),
+		attachmentsCollection:  mongoDatabase.Collection("attachments"),
+		clientMergesCollection: mongoDatabase.Collection("clientMerges"),
+	}
 }
 
-func (m mongodb) GetClients(ctx context.Context, offset int, limit int) ([]models.Client, error) {
+func notDeletedFilter(filter bson.D, includeDeleted bool) bson.D {
+	if includeDeleted {
+		return filter
+	}
+	return append(filter, bson.E{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}})
+}
 
-	var clients []models.Client
+// dbError classifies driver errors the caller can act on, other errors are returned as they are.
+func dbError(err error) error {
+
+	if err == mongo.ErrNoDocuments {
+		return apperrors.NotFound("not found")
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return apperrors.Wrap(apperrors.KindConflict, err)
+	}
+	return err
+}
+
+// nextId emulates an identity column, mongo documents are addressed by the integer "id" field.
+func nextId(ctx context.Context, collection *mongo.Collection) (int, error) {
+	return reserveIds(ctx, collection, 1)
+}
 
-	filter := bson.D{}
-	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
+// reserveIds takes count ids from the counter of the collection in the counters collection and returns
+// the first of them. The increment is atomic, concurrent inserts never get the same id.
+func reserveIds(ctx context.Context, collection *mongo.Collection, count int) (int, error) {
 
-	cursor, err := m.clientsCollection.Find(ctx, filter, opts)
+	counters := collection.Database().Collection("counters")
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := counters.FindOneAndUpdate(ctx,
+		bson.D{{Key: "_id", Value: collection.Name()}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: count}}}},
+		opts,
+	).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+
+	return counter.Seq - count + 1, nil
+}
+
+func (m mongodb) GetClients(ctx context.Context, query models.ListQuery) ([]models.Client, error) {
+
+	var clients []models.Client
+
+	cursor, err := m.findClients(ctx, query)
 	if err != nil {
-		log.Println(err)
 		return clients, err
 	}
 
@@ -59,13 +136,83 @@ func (m mongodb) GetClients(ctx context.Context, offset int, limit int) ([]model
 	return clients, nil
 }
 
-func (m mongodb) GetClientById(ctx context.Context, id int) (models.Client, error) {
+func (m mongodb) ExportClients(ctx context.Context, query models.ListQuery, each func(models.Client) error) error {
+
+	cursor, err := m.findClients(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var client models.Client
+		err = cursor.Decode(&client)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		err = each(client)
+		if err != nil {
+			return err
+		}
+	}
+	err = cursor.Err()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (m mongodb) findClients(ctx context.Context, query models.ListQuery) (*mongo.Cursor, error) {
+
+	filter, err := toBson(query.Filter, clientKeysByField)
+	if err != nil {
+		return nil, err
+	}
+	filter = notDeletedFilter(filter, query.IncludeDeleted)
+	sort, err := sortBson(query.Sort, clientKeysByField)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(sort).SetLimit(int64(query.Limit)).SetSkip(int64(query.Offset))
+
+	cursor, err := m.clientsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return cursor, nil
+}
+
+func (m mongodb) CountClients(ctx context.Context, query models.ListQuery) (int64, error) {
 
-	filter := bson.D{{"id", id}}
+	filter, err := toBson(query.Filter, clientKeysByField)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := m.clientsCollection.CountDocuments(ctx, notDeletedFilter(filter, query.IncludeDeleted))
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m mongodb) GetClientById(ctx context.Context, id int, includeDeleted bool) (models.Client, error) {
+
+	filter := notDeletedFilter(bson.D{{Key: "id", Value: id}}, includeDeleted)
 
 	var client models.Client
 	err := m.clientsCollection.FindOne(ctx, filter).Decode(&client)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Client{}, apperrors.NotFound("No client found by id %d", id)
+		}
 		log.Println(err)
 		return models.Client{}, err
 	}
@@ -75,7 +222,17 @@ func (m mongodb) GetClientById(ctx context.Context, id int) (models.Client, erro
 
 func (m mongodb) CreateClient(ctx context.Context, client models.Client) (models.Client, error) {
 
-	_, err := m.clientsCollection.InsertOne(ctx, client)
+	var err error
+	client.Id, err = nextId(ctx, m.clientsCollection)
+	if err != nil {
+		log.Println(err)
+		return models.Client{}, err
+	}
+	client.CreatedAt = time.Now().UTC()
+	client.UpdatedAt = client.CreatedAt
+	client.Version = 1
+
+	_, err = m.clientsCollection.InsertOne(ctx, client)
 	if err != nil {
 		log.Println(err)
 		return models.Client{}, err
@@ -84,10 +241,45 @@ func (m mongodb) CreateClient(ctx context.Context, client models.Client) (models
 	return client, nil
 }
 
+// versionFilter narrows a filter to the expected version unless it is zero.
+func versionFilter(filter bson.D, version int) bson.D {
+	if version == 0 {
+		return filter
+	}
+	return append(filter, bson.E{Key: "version", Value: version})
+}
+
+// missingOrConflict tells why a versioned change of a client matched no document.
+func (m mongodb) missingOrConflict(ctx context.Context, id int) error {
+
+	count, err := m.clientsCollection.CountDocuments(ctx, notDeletedFilter(bson.D{{Key: "id", Value: id}}, false))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if count > 0 {
+		return interfaces.ErrVersionConflict
+	}
+	return apperrors.NotFound("no rows affected")
+}
+
 func (m mongodb) UpdateClient(ctx context.Context, client models.Client) error {
 
-	filter := bson.D{{"id", client.Id}}
-	update := bson.D{{"$set", bson.D{{"name", client.Name}}}}
+	filter := versionFilter(notDeletedFilter(bson.D{{Key: "id", Value: client.Id}}, false), client.Version)
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "name", Value: client.Name},
+			{Key: "email", Value: client.Email},
+			{Key: "phone", Value: client.Phone},
+			{Key: "address", Value: client.Address},
+			{Key: "taxId", Value: client.TaxId},
+			{Key: "status", Value: client.Status},
+			{Key: "tags", Value: client.Tags},
+			{Key: "customFields", Value: client.WithDefaults().CustomFields},
+			{Key: "updatedAt", Value: time.Now().UTC()},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
 
 	updateResult, err := m.clientsCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -96,24 +288,101 @@ func (m mongodb) UpdateClient(ctx context.Context, client models.Client) error {
 		log.Println(err)
 		return err
 	}
-	if updateResult.ModifiedCount == 0 {
-		return errors.New("no rows affected")
+	if updateResult.MatchedCount == 0 {
+		return m.missingOrConflict(ctx, client.Id)
 	}
 
 	return nil
 }
 
-func (m mongodb) DeleteClient(ctx context.Context, id int) error {
+// clientDependents are the collections whose documents are soft-deleted and restored together with their client.
+func (m mongodb) clientDependents() []*mongo.Collection {
+	return []*mongo.Collection{m.contactsCollection, m.activitiesCollection, m.attachmentsCollection}
+}
+
+func (m mongodb) DeleteClient(ctx context.Context, id int, version int) error {
 
-	filter := bson.D{{"id", id}}
+	deletedAt := time.Now().UTC()
+	filter := versionFilter(notDeletedFilter(bson.D{{Key: "id", Value: id}}, false), version)
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: deletedAt}}}}
 
-	deleteResult, err := m.clientsCollection.DeleteOne(ctx, filter)
+	updateResult, err := m.clientsCollection.UpdateOne(ctx, filter, append(update, bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}))
 	if err != nil {
 		return err
 	}
-	if deleteResult.DeletedCount == 0 {
-		return errors.New("no rows affected")
+	if updateResult.ModifiedCount == 0 {
+		return m.missingOrConflict(ctx, id)
+	}
+
+	dependentsFilter := notDeletedFilter(bson.D{{Key: "clientId", Value: id}}, false)
+	for _, collection := range m.clientDependents() {
+		_, err = collection.UpdateMany(ctx, dependentsFilter, update)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
 	}
 
 	return nil
 }
+
+func (m mongodb) RestoreClient(ctx context.Context, id int) (models.Client, error) {
+
+	filter := bson.D{{Key: "id", Value: id}, {Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: true}}}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "deletedAt", Value: ""}}}}
+
+	var deleted models.Client
+	err := m.clientsCollection.FindOneAndUpdate(ctx, filter, append(update, bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}})).Decode(&deleted)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Client{}, apperrors.NotFound("No deleted client found by id %d", id)
+		}
+		log.Println(err)
+		return models.Client{}, err
+	}
+
+	// only documents deleted together with the client come back, not the ones deleted before it
+	dependentsFilter := bson.D{{Key: "clientId", Value: id}, {Key: "deletedAt", Value: deleted.DeletedAt}}
+	for _, collection := range m.clientDependents() {
+		_, err = collection.UpdateMany(ctx, dependentsFilter, update)
+		if err != nil {
+			log.Println(err)
+			return models.Client{}, err
+		}
+	}
+
+	deleted.DeletedAt = nil
+	deleted.Version++
+	return deleted, nil
+}
+
+func (m mongodb) PurgeDeletedClients(ctx context.Context, deletedBefore time.Time) (int64, error) {
+
+	filter := bson.D{{Key: "deletedAt", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}}
+
+	ids, err := m.clientsCollection.Distinct(ctx, "id", filter)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	dependentsFilter := bson.D{{Key: "clientId", Value: bson.D{{Key: "$in", Value: ids}}}}
+	for _, collection := range m.clientDependents() {
+		_, err = collection.DeleteMany(ctx, dependentsFilter)
+		if err != nil {
+			log.Println(err)
+			return 0, err
+		}
+	}
+
+	deleteResult, err := m.clientsCollection.DeleteMany(ctx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return deleteResult.DeletedCount, nil
+}
@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+// clientField maps a patchable client field to the value to store, documents use the json names.
+func clientField(client models.Client, field string) (interface{}, error) {
+
+	switch field {
+	case "name":
+		return client.Name, nil
+	case "email":
+		return client.Email, nil
+	case "phone":
+		return client.Phone, nil
+	case "address":
+		return client.Address, nil
+	case "taxId":
+		return client.TaxId, nil
+	case "status":
+		return client.Status, nil
+	case "tags":
+		return client.WithDefaults().Tags, nil
+	case "customFields":
+		return client.WithDefaults().CustomFields, nil
+	}
+	return nil, apperrors.BadRequest("field %s can not be patched", field)
+}
+
+func (m mongodb) PatchClient(ctx context.Context, client models.Client, fields []string) error {
+
+	set := bson.D{}
+	for _, field := range fields {
+		value, err := clientField(client, field)
+		if err != nil {
+			return err
+		}
+		set = append(set, bson.E{Key: field, Value: value})
+	}
+	set = append(set, bson.E{Key: "updatedAt", Value: time.Now().UTC()})
+
+	filter := versionFilter(notDeletedFilter(bson.D{{Key: "id", Value: client.Id}}, false), client.Version)
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+
+	updateResult, err := m.clientsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if updateResult.MatchedCount == 0 {
+		return m.missingOrConflict(ctx, client.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"testApplication/models"
+	"testApplication/search"
+)
+
+// fuzzyCandidates caps the documents sharing a trigram with the terms that get ranked in memory.
+const fuzzyCandidates = 500
+
+type scoredClient struct {
+	models.Client `bson:",inline"`
+	Score         float64 `bson:"score"`
+}
+
+func (m mongodb) SearchClients(ctx context.Context, terms []string, limit int) ([]models.ClientSearchResult, error) {
+
+	results := []models.ClientSearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	// the text index finds whole words in every searched field
+	textFilter := notDeletedFilter(bson.D{{Key: "$text", Value: bson.D{
+		{Key: "$search", Value: strings.Join(terms, " ")},
+		{Key: "$language", Value: "none"},
+	}}}, false)
+	score := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+	textOpts := options.Find().SetProjection(score).SetSort(score).SetLimit(int64(limit))
+
+	cursor, err := m.clientsCollection.Find(ctx, textFilter, textOpts)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	var textMatches []scoredClient
+	err = cursor.All(ctx, &textMatches)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+
+	// text indexes know neither prefixes nor typos, names sharing a trigram with the terms are ranked here instead
+	fragments := search.Fragments(terms)
+	for i, fragment := range fragments {
+		fragments[i] = regexp.QuoteMeta(fragment)
+	}
+	fuzzyFilter := notDeletedFilter(bson.D{{Key: "name", Value: bson.D{
+		{Key: "$regex", Value: strings.Join(fragments, "|")},
+		{Key: "$options", Value: "i"},
+	}}}, false)
+
+	cursor, err = m.clientsCollection.Find(ctx, fuzzyFilter, options.Find().SetLimit(fuzzyCandidates))
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	var candidates []models.Client
+	err = cursor.All(ctx, &candidates)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+
+	ranks := map[int]float64{}
+	clients := map[int]models.Client{}
+	for _, match := range textMatches {
+		ranks[match.Id] += match.Score
+		clients[match.Id] = match.Client
+	}
+	for _, candidate := range candidates {
+		rank := search.Rank(terms, candidate.Name)
+		if rank < search.MinSimilarity {
+			continue
+		}
+		ranks[candidate.Id] += rank
+		clients[candidate.Id] = candidate
+	}
+
+	for id, client := range clients {
+		results = append(results, search.ClientResult(client, terms, ranks[id]))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Client.Id < results[j].Client.Id
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
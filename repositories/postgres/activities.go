@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+const activityColumns = "id, client_id, author_id, kind, body, created_at, updated_at"
+
+func scanActivity(row rowScanner) (models.Activity, error) {
+
+	var activity models.Activity
+	err := row.Scan(
+		&activity.Id,
+		&activity.ClientId,
+		&activity.AuthorId,
+		&activity.Kind,
+		&activity.Body,
+		&activity.CreatedAt,
+		&activity.UpdatedAt,
+	)
+
+	return activity, err
+}
+
+func (pg *postgres) GetActivities(ctx context.Context, clientId int, after models.ActivityCursor, limit int) ([]models.Activity, error) {
+	var activities []models.Activity
+
+	activitiesStmt, err := pg.db.Prepare(
+		"SELECT " + activityColumns + " FROM activities" +
+			" WHERE client_id = $1 AND deleted_at IS NULL AND ($2 OR (created_at, id) < ($3, $4))" +
+			" ORDER BY created_at DESC, id DESC LIMIT $5",
+	)
+	if err != nil {
+		log.Println(err)
+		return activities, err
+	}
+	defer activitiesStmt.Close()
+
+	rows, err := activitiesStmt.Query(clientId, after.Id == 0, after.CreatedAt, after.Id, limit)
+	if err != nil {
+		log.Println(err)
+		return activities, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		activity, err := scanActivity(rows)
+		if err != nil {
+			log.Println(err)
+			return activities, err
+		}
+		activities = append(activities, activity)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return activities, err
+	}
+
+	return activities, nil
+}
+
+func (pg *postgres) GetActivityById(ctx context.Context, clientId int, id int) (models.Activity, error) {
+
+	activityByIdStmt, err := pg.db.Prepare("SELECT " + activityColumns + " FROM activities WHERE client_id = $1 AND id = $2 AND deleted_at IS NULL")
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+	defer activityByIdStmt.Close()
+
+	activity, err := scanActivity(activityByIdStmt.QueryRow(clientId, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Activity{}, apperrors.NotFound("No activity found by id %d", id)
+		}
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return activity, nil
+}
+
+func (pg *postgres) CreateActivity(ctx context.Context, newActivity models.Activity) (models.Activity, error) {
+
+	insertActivityStmt, err := pg.db.Prepare(
+		"INSERT INTO activities(client_id, author_id, kind, body)" +
+			" SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)" +
+			" returning " + activityColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+	defer insertActivityStmt.Close()
+
+	activity, err := scanActivity(insertActivityStmt.QueryRow(newActivity.ClientId, newActivity.AuthorId, newActivity.Kind, newActivity.Body))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Activity{}, apperrors.NotFound("No client found by id %d", newActivity.ClientId)
+		}
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return activity, nil
+}
+
+func (pg *postgres) UpdateActivity(ctx context.Context, activity models.Activity) (models.Activity, error) {
+
+	updateActivityStmt, err := pg.db.Prepare(
+		"UPDATE activities SET kind = $1, body = $2, updated_at = now()" +
+			" WHERE client_id = $3 AND id = $4 AND deleted_at IS NULL returning " + activityColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.Activity{}, err
+	}
+	defer updateActivityStmt.Close()
+
+	updated, err := scanActivity(updateActivityStmt.QueryRow(activity.Kind, activity.Body, activity.ClientId, activity.Id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Activity{}, apperrors.NotFound("No activity found by id %d", activity.Id)
+		}
+		log.Println(err)
+		return models.Activity{}, err
+	}
+
+	return updated, nil
+}
+
+func (pg *postgres) DeleteActivity(ctx context.Context, clientId int, id int) error {
+
+	deleteActivityStmt, err := pg.db.Prepare("UPDATE activities SET deleted_at = now() WHERE client_id = $1 AND id = $2 AND deleted_at IS NULL")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer deleteActivityStmt.Close()
+
+	res, err := deleteActivityStmt.Exec(clientId, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
+
+func (pg *postgres) PurgeDeletedActivities(ctx context.Context, deletedBefore time.Time) (int64, error) {
+
+	purgeActivitiesStmt, err := pg.db.Prepare("DELETE FROM activities WHERE deleted_at < $1")
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	defer purgeActivitiesStmt.Close()
+
+	res, err := purgeActivitiesStmt.Exec(deletedBefore)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
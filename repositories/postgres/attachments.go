@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+const attachmentColumns = "id, client_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at"
+
+func scanAttachment(row rowScanner) (models.Attachment, error) {
+
+	var attachment models.Attachment
+	err := row.Scan(
+		&attachment.Id,
+		&attachment.ClientId,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.Checksum,
+		&attachment.StorageKey,
+		&attachment.UploadedBy,
+		&attachment.CreatedAt,
+	)
+
+	return attachment, err
+}
+
+func (pg *postgres) GetAttachments(ctx context.Context, clientId int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+
+	attachmentsStmt, err := pg.db.Prepare("SELECT " + attachmentColumns + " FROM attachments WHERE client_id = $1 AND deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		log.Println(err)
+		return attachments, err
+	}
+	defer attachmentsStmt.Close()
+
+	rows, err := attachmentsStmt.Query(clientId)
+	if err != nil {
+		log.Println(err)
+		return attachments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			log.Println(err)
+			return attachments, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return attachments, err
+	}
+
+	return attachments, nil
+}
+
+func (pg *postgres) GetAttachmentById(ctx context.Context, clientId int, id int) (models.Attachment, error) {
+
+	attachmentByIdStmt, err := pg.db.Prepare("SELECT " + attachmentColumns + " FROM attachments WHERE client_id = $1 AND id = $2 AND deleted_at IS NULL")
+	if err != nil {
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+	defer attachmentByIdStmt.Close()
+
+	attachment, err := scanAttachment(attachmentByIdStmt.QueryRow(clientId, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Attachment{}, apperrors.NotFound("No attachment found by id %d", id)
+		}
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (pg *postgres) CreateAttachment(ctx context.Context, newAttachment models.Attachment) (models.Attachment, error) {
+
+	insertAttachmentStmt, err := pg.db.Prepare(
+		"INSERT INTO attachments(client_id, file_name, content_type, size, checksum, storage_key, uploaded_by)" +
+			" SELECT $1, $2, $3, $4, $5, $6, $7 WHERE EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)" +
+			" returning " + attachmentColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+	defer insertAttachmentStmt.Close()
+
+	attachment, err := scanAttachment(insertAttachmentStmt.QueryRow(
+		newAttachment.ClientId,
+		newAttachment.FileName,
+		newAttachment.ContentType,
+		newAttachment.Size,
+		newAttachment.Checksum,
+		newAttachment.StorageKey,
+		newAttachment.UploadedBy,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Attachment{}, apperrors.NotFound("No client found by id %d", newAttachment.ClientId)
+		}
+		log.Println(err)
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (pg *postgres) DeleteAttachment(ctx context.Context, clientId int, id int) error {
+
+	deleteAttachmentStmt, err := pg.db.Prepare("DELETE FROM attachments WHERE client_id = $1 AND id = $2")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer deleteAttachmentStmt.Close()
+
+	res, err := deleteAttachmentStmt.Exec(clientId, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
+
+func (pg *postgres) PurgeDeletedAttachments(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	var keys []string
+
+	purgeAttachmentsStmt, err := pg.db.Prepare("DELETE FROM attachments WHERE deleted_at < $1 returning storage_key")
+	if err != nil {
+		log.Println(err)
+		return keys, err
+	}
+	defer purgeAttachmentsStmt.Close()
+
+	rows, err := purgeAttachmentsStmt.Query(deletedBefore)
+	if err != nil {
+		log.Println(err)
+		return keys, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			log.Println(err)
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+// runClientOperation applies one batch operation within the transaction.
+func runClientOperation(tx *sql.Tx, operation models.ClientOperation) (models.ClientOperationResult, error) {
+
+	result := models.ClientOperationResult{Op: operation.Op, Id: operation.Id}
+
+	var err error
+	switch operation.Op {
+	case models.ClientOpCreate:
+		var client models.Client
+		client, err = createClient(tx, operation.Client)
+		if err == nil {
+			result.Id, result.Client = client.Id, &client
+		}
+	case models.ClientOpUpdate:
+		operation.Client.Id = operation.Id
+		err = updateClient(tx, operation.Client)
+		if err == nil {
+			var client models.Client
+			client, err = scanClient(tx.QueryRow("SELECT "+clientColumns+" FROM clients WHERE id = $1", operation.Id))
+			result.Client = &client
+		}
+	case models.ClientOpDelete:
+		err = deleteClient(tx, operation.Id, operation.Version)
+	default:
+		err = apperrors.BadRequest("unknown operation %s", operation.Op)
+	}
+
+	if err != nil {
+		result.Client = nil
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+func (pg *postgres) BatchClients(ctx context.Context, operations []models.ClientOperation, atomic bool) ([]models.ClientOperationResult, error) {
+
+	results := make([]models.ClientOperationResult, 0, len(operations))
+
+	if atomic {
+		tx, err := pg.db.BeginTx(ctx, nil)
+		if err != nil {
+			log.Println(err)
+			return results, err
+		}
+		defer tx.Rollback()
+
+		for i, operation := range operations {
+			result, err := runClientOperation(tx, operation)
+			result.Index = i
+			results = append(results, result)
+			if err != nil {
+				return results, err
+			}
+		}
+		return results, tx.Commit()
+	}
+
+	for i, operation := range operations {
+
+		tx, err := pg.db.BeginTx(ctx, nil)
+		if err != nil {
+			log.Println(err)
+			return results, err
+		}
+
+		result, err := runClientOperation(tx, operation)
+		if err == nil {
+			err = tx.Commit()
+			if err != nil {
+				result.Client = nil
+				result.Error = err.Error()
+			}
+		} else {
+			tx.Rollback()
+		}
+		result.Index = i
+		results = append(results, result)
+	}
+
+	return results, nil
+}
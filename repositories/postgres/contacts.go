@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+const contactColumns = "id, client_id, name, email, phone, position, is_primary"
+
+func scanContact(row rowScanner) (models.Contact, error) {
+
+	var contact models.Contact
+	err := row.Scan(
+		&contact.Id,
+		&contact.ClientId,
+		&contact.Name,
+		&contact.Email,
+		&contact.Phone,
+		&contact.Position,
+		&contact.Primary,
+	)
+
+	return contact, err
+}
+
+func (pg *postgres) GetContacts(ctx context.Context, clientId int) ([]models.Contact, error) {
+	var contacts []models.Contact
+
+	contactsStmt, err := pg.db.Prepare("SELECT " + contactColumns + " FROM contacts WHERE client_id = $1 AND deleted_at IS NULL ORDER BY is_primary DESC, id")
+	if err != nil {
+		log.Println(err)
+		return contacts, err
+	}
+	defer contactsStmt.Close()
+
+	rows, err := contactsStmt.Query(clientId)
+	if err != nil {
+		log.Println(err)
+		return contacts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			log.Println(err)
+			return contacts, err
+		}
+		contacts = append(contacts, contact)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return contacts, err
+	}
+
+	return contacts, nil
+}
+
+func (pg *postgres) GetContactById(ctx context.Context, clientId int, id int) (models.Contact, error) {
+
+	contactByIdStmt, err := pg.db.Prepare("SELECT " + contactColumns + " FROM contacts WHERE client_id = $1 AND id = $2 AND deleted_at IS NULL")
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+	defer contactByIdStmt.Close()
+
+	contact, err := scanContact(contactByIdStmt.QueryRow(clientId, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Contact{}, apperrors.NotFound("No contact found by id %d", id)
+		}
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	return contact, nil
+}
+
+// resetPrimaryContact clears the primary flag of every other contact of the client,
+// a client has at most one primary contact.
+func resetPrimaryContact(tx *sql.Tx, contact models.Contact) error {
+
+	if !contact.Primary {
+		return nil
+	}
+
+	_, err := tx.Exec("UPDATE contacts SET is_primary = FALSE WHERE client_id = $1 AND id <> $2 AND is_primary", contact.ClientId, contact.Id)
+	return err
+}
+
+func (pg *postgres) CreateContact(ctx context.Context, newContact models.Contact) (models.Contact, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+	defer tx.Rollback()
+
+	err = resetPrimaryContact(tx, newContact)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	contact, err := scanContact(tx.QueryRow(
+		"INSERT INTO contacts(client_id, name, email, phone, position, is_primary)"+
+			" SELECT $1, $2, $3, $4, $5, $6 WHERE EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)"+
+			" returning "+contactColumns,
+		newContact.ClientId,
+		newContact.Name,
+		newContact.Email,
+		newContact.Phone,
+		newContact.Position,
+		newContact.Primary,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Contact{}, apperrors.NotFound("No client found by id %d", newContact.ClientId)
+		}
+		log.Println(err)
+		return models.Contact{}, dbError(err)
+	}
+
+	return contact, tx.Commit()
+}
+
+func (pg *postgres) UpdateContact(ctx context.Context, contact models.Contact) (models.Contact, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+	defer tx.Rollback()
+
+	err = resetPrimaryContact(tx, contact)
+	if err != nil {
+		log.Println(err)
+		return models.Contact{}, err
+	}
+
+	updated, err := scanContact(tx.QueryRow(
+		"UPDATE contacts SET name = $1, email = $2, phone = $3, position = $4, is_primary = $5"+
+			" WHERE client_id = $6 AND id = $7 AND deleted_at IS NULL returning "+contactColumns,
+		contact.Name,
+		contact.Email,
+		contact.Phone,
+		contact.Position,
+		contact.Primary,
+		contact.ClientId,
+		contact.Id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Contact{}, apperrors.NotFound("No contact found by id %d", contact.Id)
+		}
+		log.Println(err)
+		return models.Contact{}, dbError(err)
+	}
+
+	return updated, tx.Commit()
+}
+
+func (pg *postgres) DeleteContact(ctx context.Context, clientId int, id int) error {
+
+	deleteContactStmt, err := pg.db.Prepare("DELETE FROM contacts WHERE client_id = $1 AND id = $2")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer deleteContactStmt.Close()
+
+	res, err := deleteContactStmt.Exec(clientId, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+func (pg *postgres) GetCustomFields(ctx context.Context) ([]models.CustomField, error) {
+	var fields []models.CustomField
+
+	fieldsStmt, err := pg.db.Prepare("SELECT id, name, type, required, enum_values FROM custom_fields ORDER BY id")
+	if err != nil {
+		log.Println(err)
+		return fields, err
+	}
+	defer fieldsStmt.Close()
+
+	rows, err := fieldsStmt.Query()
+	if err != nil {
+		log.Println(err)
+		return fields, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var field models.CustomField
+		err = rows.Scan(&field.Id, &field.Name, &field.Type, &field.Required, pq.Array(&field.EnumValues))
+		if err != nil {
+			log.Println(err)
+			return fields, err
+		}
+		fields = append(fields, field)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return fields, err
+	}
+
+	return fields, nil
+}
+
+func (pg *postgres) CreateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error) {
+
+	insertFieldStmt, err := pg.db.Prepare("INSERT INTO custom_fields(name, type, required, enum_values) VALUES($1, $2, $3, $4) returning id")
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+	defer insertFieldStmt.Close()
+
+	err = insertFieldStmt.QueryRow(field.Name, field.Type, field.Required, pq.Array(field.EnumValues)).Scan(&field.Id)
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, dbError(err)
+	}
+
+	return field, nil
+}
+
+func (pg *postgres) UpdateCustomField(ctx context.Context, field models.CustomField) (models.CustomField, error) {
+
+	updateFieldStmt, err := pg.db.Prepare("UPDATE custom_fields SET name = $1, type = $2, required = $3, enum_values = $4 WHERE id = $5")
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+	defer updateFieldStmt.Close()
+
+	res, err := updateFieldStmt.Exec(field.Name, field.Type, field.Required, pq.Array(field.EnumValues), field.Id)
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, dbError(err)
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return models.CustomField{}, err
+	}
+	if rowCount == 0 {
+		return models.CustomField{}, apperrors.NotFound("No custom field found by id %d", field.Id)
+	}
+
+	return field, nil
+}
+
+func (pg *postgres) DeleteCustomField(ctx context.Context, id int) error {
+
+	deleteFieldStmt, err := pg.db.Prepare("DELETE FROM custom_fields WHERE id = $1")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer deleteFieldStmt.Close()
+
+	res, err := deleteFieldStmt.Exec(id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return apperrors.NotFound("no rows affected")
+	}
+
+	return nil
+}
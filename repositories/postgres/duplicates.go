@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+func (pg *postgres) DuplicateCandidates(ctx context.Context, query models.DuplicateQuery, limit int) ([]models.Client, error) {
+
+	var clients []models.Client
+
+	tx, err := pg.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+	defer tx.Rollback()
+
+	err = setSimilarityThreshold(tx)
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+
+	candidatesStmt, err := tx.Prepare(
+		"SELECT " + clientColumns + " FROM clients" +
+			" WHERE deleted_at IS NULL AND id <> $1 AND (" +
+			" ($2 <> '' AND regexp_replace(lower(tax_id), '[^[:alnum:]]', '', 'g') = $2)" +
+			" OR ($3 <> '' AND lower(split_part(email, '@', 2)) = $3)" +
+			" OR ($4 <> '' AND $4 <% name))" +
+			" ORDER BY word_similarity($4, name) DESC, id LIMIT $5",
+	)
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+	defer candidatesStmt.Close()
+
+	rows, err := candidatesStmt.Query(query.ExcludeId, query.TaxId, query.EmailDomain, strings.Join(query.NameTerms, " "), limit)
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		client, err := scanClient(rows)
+		if err != nil {
+			log.Println(err)
+			return clients, err
+		}
+		clients = append(clients, client)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return clients, err
+	}
+
+	return clients, nil
+}
+
+func (pg *postgres) MergeClients(ctx context.Context, target models.Client, sourceId int, mergedBy int) (models.ClientMerge, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+	defer tx.Rollback()
+
+	source, err := scanClient(tx.QueryRow("SELECT "+clientColumns+" FROM clients WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", sourceId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ClientMerge{}, apperrors.NotFound("No client found by id %d", sourceId)
+		}
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	err = updateClient(tx, target)
+	if err != nil {
+		return models.ClientMerge{}, err
+	}
+
+	// a client has one primary contact, the target one stays primary
+	_, err = tx.Exec(
+		"UPDATE contacts SET is_primary = FALSE WHERE client_id = $1 AND is_primary"+
+			" AND EXISTS (SELECT 1 FROM contacts WHERE client_id = $2 AND is_primary AND deleted_at IS NULL)",
+		sourceId, target.Id,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	for _, table := range clientDependents {
+		_, err = tx.Exec("UPDATE "+table+" SET client_id = $1 WHERE client_id = $2", target.Id, sourceId)
+		if err != nil {
+			log.Println(err)
+			return models.ClientMerge{}, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE clients SET deleted_at = now(), version = version + 1 WHERE id = $1", sourceId)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	snapshot, err := json.Marshal(source)
+	if err != nil {
+		return models.ClientMerge{}, err
+	}
+
+	merge := models.ClientMerge{TargetId: target.Id, SourceId: sourceId, MergedBy: mergedBy, Source: source}
+	err = tx.QueryRow(
+		"INSERT INTO client_merges(target_id, source_id, merged_by, source) VALUES($1, $2, $3, $4) returning id, merged_at",
+		target.Id, sourceId, mergedBy, snapshot,
+	).Scan(&merge.Id, &merge.MergedAt)
+	if err != nil {
+		log.Println(err)
+		return models.ClientMerge{}, err
+	}
+
+	return merge, tx.Commit()
+}
+
+func (pg *postgres) GetClientMerges(ctx context.Context, targetId int) ([]models.ClientMerge, error) {
+
+	merges := []models.ClientMerge{}
+
+	mergesStmt, err := pg.db.Prepare("SELECT id, target_id, source_id, merged_by, merged_at, source FROM client_merges WHERE target_id = $1 ORDER BY merged_at DESC, id DESC")
+	if err != nil {
+		log.Println(err)
+		return merges, err
+	}
+	defer mergesStmt.Close()
+
+	rows, err := mergesStmt.Query(targetId)
+	if err != nil {
+		log.Println(err)
+		return merges, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			merge  models.ClientMerge
+			source []byte
+		)
+		err = rows.Scan(&merge.Id, &merge.TargetId, &merge.SourceId, &merge.MergedBy, &merge.MergedAt, &source)
+		if err != nil {
+			log.Println(err)
+			return merges, err
+		}
+		err = json.Unmarshal(source, &merge.Source)
+		if err != nil {
+			log.Println(err)
+			return merges, err
+		}
+		merges = append(merges, merge)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return merges, err
+	}
+
+	return merges, nil
+}
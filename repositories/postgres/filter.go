@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"testApplication/query"
+)
+
+var clientColumnsByField = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"email":     "email",
+	"phone":     "phone",
+	"taxId":     "tax_id",
+	"status":    "status",
+	"tags":      "tags",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+var userColumnsByField = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"email": "email",
+}
+
+// sqlBuilder translates filter expressions into SQL, every value becomes a positional parameter.
+type sqlBuilder struct {
+	args    []any
+	columns map[string]string
+}
+
+func (builder *sqlBuilder) arg(value any) string {
+	builder.args = append(builder.args, value)
+	return fmt.Sprintf("$%d", len(builder.args))
+}
+
+func (builder *sqlBuilder) column(condition query.Condition) (string, error) {
+
+	if name, found := strings.CutPrefix(condition.Field, query.CustomFieldsPrefix); found {
+		column := "custom_fields ->> " + builder.arg(name) + "::text"
+		switch condition.Type {
+		case query.Number:
+			return "(" + column + ")::numeric", nil
+		case query.Bool:
+			return "(" + column + ")::boolean", nil
+		}
+		return "(" + column + ")", nil
+	}
+
+	column, ok := builder.columns[condition.Field]
+	if !ok {
+		return "", fmt.Errorf("field %s is not mapped to a column", condition.Field)
+	}
+	return column, nil
+}
+
+func (builder *sqlBuilder) where(expr query.Expr) (string, error) {
+
+	switch expr := expr.(type) {
+	case nil:
+		return "TRUE", nil
+	case query.And:
+		return builder.join(expr, " AND ")
+	case query.Or:
+		return builder.join(expr, " OR ")
+	case query.Condition:
+		return builder.condition(expr)
+	}
+	return "", fmt.Errorf("unsupported filter expression %T", expr)
+}
+
+func (builder *sqlBuilder) join(exprs []query.Expr, separator string) (string, error) {
+
+	if len(exprs) == 0 {
+		return "TRUE", nil
+	}
+
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		part, err := builder.where(expr)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return "(" + strings.Join(parts, separator) + ")", nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (builder *sqlBuilder) condition(condition query.Condition) (string, error) {
+
+	column, err := builder.column(condition)
+	if err != nil {
+		return "", err
+	}
+
+	if condition.Type == query.StringList {
+		membership := builder.arg(condition.Value) + " = ANY(" + column + ")"
+		if condition.Op == query.Ne {
+			return "NOT " + membership, nil
+		}
+		return membership, nil
+	}
+
+	switch condition.Op {
+	case query.Contains:
+		return column + " ILIKE " + builder.arg("%"+escapeLike(fmt.Sprint(condition.Value))+"%"), nil
+	case query.In:
+		values, _ := condition.Value.([]interface{})
+		placeholders := make([]string, 0, len(values))
+		for _, value := range values {
+			placeholders = append(placeholders, builder.arg(value))
+		}
+		return column + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+	case query.Eq, query.Ne, query.Gt, query.Gte, query.Lt, query.Lte:
+		op := string(condition.Op)
+		if condition.Op == query.Ne {
+			op = "<>"
+		}
+		return column + " " + op + " " + builder.arg(condition.Value), nil
+	}
+	return "", fmt.Errorf("unsupported operator %s", condition.Op)
+}
+
+func (builder *sqlBuilder) orderBy(sorts []query.Sort) (string, error) {
+
+	if len(sorts) == 0 {
+		return " ORDER BY id", nil
+	}
+
+	parts := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		column, ok := builder.columns[sort.Field]
+		if !ok {
+			return "", fmt.Errorf("field %s is not mapped to a column", sort.Field)
+		}
+		if sort.Desc {
+			column += " DESC"
+		}
+		parts = append(parts, column)
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
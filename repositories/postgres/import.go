@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	"testApplication/models"
+)
+
+// importBatch is how many rows are copied between progress reports.
+const importBatch = 1000
+
+func (pg *postgres) ImportClients(ctx context.Context, clients []models.Client, progress func(imported int)) ([]models.Client, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	copyStmt, err := tx.Prepare(pq.CopyIn("clients", "name", "email", "phone", "address", "tax_id", "status", "tags", "custom_fields"))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer copyStmt.Close()
+
+	for i, client := range clients {
+
+		client = client.WithDefaults()
+		customFields, err := json.Marshal(client.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+
+		// COPY would send []byte as bytea, jsonb needs the text
+		_, err = copyStmt.Exec(
+			client.Name,
+			client.Email,
+			client.Phone,
+			client.Address,
+			client.TaxId,
+			client.Status,
+			pq.Array(client.Tags),
+			string(customFields),
+		)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		if (i+1)%importBatch == 0 {
+			progress(i + 1)
+		}
+	}
+
+	_, err = copyStmt.Exec()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	// COPY returns no ids, the rows this transaction inserted are read back
+	rows, err := tx.QueryContext(ctx, "SELECT "+clientColumns+" FROM clients WHERE xmin::text::bigint = txid_current() % 4294967296 ORDER BY id")
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	imported := make([]models.Client, 0, len(clients))
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		imported = append(imported, client)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	progress(len(clients))
+	return imported, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/models"
+)
+
+// clientColumn maps a patchable client field to its column and the value to store.
+func clientColumn(client models.Client, field string) (string, interface{}, error) {
+
+	switch field {
+	case "name":
+		return "name", client.Name, nil
+	case "email":
+		return "email", client.Email, nil
+	case "phone":
+		return "phone", client.Phone, nil
+	case "address":
+		return "address", client.Address, nil
+	case "taxId":
+		return "tax_id", client.TaxId, nil
+	case "status":
+		return "status", client.Status, nil
+	case "tags":
+		return "tags", pq.Array(client.WithDefaults().Tags), nil
+	case "customFields":
+		customFields, err := json.Marshal(client.WithDefaults().CustomFields)
+		return "custom_fields", customFields, err
+	}
+	return "", nil, apperrors.BadRequest("field %s can not be patched", field)
+}
+
+func (pg *postgres) PatchClient(ctx context.Context, client models.Client, fields []string) error {
+
+	var (
+		assignments []string
+		values      []interface{}
+	)
+	for _, field := range fields {
+		column, value, err := clientColumn(client, field)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(values)))
+	}
+	values = append(values, client.Id, client.Version)
+
+	patchClientStmt, err := pg.db.PrepareContext(ctx, fmt.Sprintf(
+		"UPDATE clients SET %s updated_at = now(), version = version + 1"+
+			" WHERE id = $%d AND deleted_at IS NULL AND ($%d = 0 OR version = $%[3]d)",
+		strings.Join(append(assignments, ""), ", "), len(values)-1, len(values),
+	))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer patchClientStmt.Close()
+
+	res, err := patchClientStmt.ExecContext(ctx, values...)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return missingOrConflict(pg.db, client.Id)
+	}
+
+	return nil
+}
+
+func (pg *postgres) PatchUser(ctx context.Context, user models.User, fields []string) (models.User, error) {
+
+	var (
+		assignments []string
+		values      []interface{}
+	)
+	for _, field := range fields {
+		switch field {
+		case "name":
+			values = append(values, user.Name)
+		case "email":
+			values = append(values, user.Email)
+		case "password":
+			values = append(values, user.Password)
+		default:
+			return models.User{}, apperrors.BadRequest("field %s can not be patched", field)
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", field, len(values)))
+	}
+	if len(assignments) == 0 {
+		return pg.ById(ctx, user.Id, false)
+	}
+	values = append(values, user.Id)
+
+	patchUserStmt, err := pg.db.PrepareContext(ctx, fmt.Sprintf(
+		"UPDATE users SET %s WHERE id = $%d AND deleted_at IS NULL",
+		strings.Join(assignments, ", "), len(values),
+	))
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+	defer patchUserStmt.Close()
+
+	res, err := patchUserStmt.ExecContext(ctx, values...)
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+	if rowCount == 0 {
+		return models.User{}, apperrors.NotFound("no rows affected")
+	}
+
+	return pg.ById(ctx, user.Id, false)
+}
@@ -3,16 +3,19 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	migratePostgres "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"log"
+	"testApplication/apperrors"
 	"testApplication/interfaces"
 	"testApplication/models"
 	"testApplication/utils"
+	"time"
 )
 
 type postgres struct {
@@ -56,106 +59,262 @@ func InitConnection() *postgres {
 	return &postgres{db: db}
 }
 
-func (pg *postgres) GetClients(ctx context.Context, offset int, limit int) ([]models.Client, error) {
+func nullTimeToPtr(nullTime sql.NullTime) *time.Time {
+	if !nullTime.Valid {
+		return nil
+	}
+	return &nullTime.Time
+}
+
+const clientColumns = "id, name, email, phone, address, tax_id, status, tags, created_at, updated_at, deleted_at, custom_fields, version"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanClient(row rowScanner) (models.Client, error) {
+
+	var (
+		client       models.Client
+		deletedAt    sql.NullTime
+		customFields []byte
+	)
+
+	err := row.Scan(
+		&client.Id,
+		&client.Name,
+		&client.Email,
+		&client.Phone,
+		&client.Address,
+		&client.TaxId,
+		&client.Status,
+		pq.Array(&client.Tags),
+		&client.CreatedAt,
+		&client.UpdatedAt,
+		&deletedAt,
+		&customFields,
+		&client.Version,
+	)
+	if err != nil {
+		return client, err
+	}
+	client.DeletedAt = nullTimeToPtr(deletedAt)
+	if client.Tags == nil {
+		client.Tags = []string{}
+	}
+
+	return client, json.Unmarshal(customFields, &client.CustomFields)
+}
+
+func (pg *postgres) GetClients(ctx context.Context, query models.ListQuery) ([]models.Client, error) {
 	var clients []models.Client
 
-	clientsStmt, err := pg.db.Prepare("SELECT * FROM clients ORDER BY id LIMIT $2 OFFSET $1")
+	err := pg.eachClient(ctx, query, func(client models.Client) error {
+		clients = append(clients, client)
+		return nil
+	})
+
+	return clients, err
+}
+
+func (pg *postgres) ExportClients(ctx context.Context, query models.ListQuery, each func(models.Client) error) error {
+	return pg.eachClient(ctx, query, each)
+}
+
+// eachClient hands the matching clients over one by one as they are read from the result set.
+func (pg *postgres) eachClient(ctx context.Context, query models.ListQuery, each func(models.Client) error) error {
+
+	builder := &sqlBuilder{args: []any{query.Offset, nil, query.IncludeDeleted}, columns: clientColumnsByField}
+	if query.Limit != 0 {
+		builder.args[1] = query.Limit
+	}
+
+	where, err := builder.where(query.Filter)
+	if err != nil {
+		return err
+	}
+	orderBy, err := builder.orderBy(query.Sort)
+	if err != nil {
+		return err
+	}
+
+	clientsStmt, err := pg.db.PrepareContext(ctx, "SELECT "+clientColumns+" FROM clients WHERE ($3 OR deleted_at IS NULL) AND "+where+orderBy+" LIMIT $2 OFFSET $1")
 	if err != nil {
 		log.Println(err)
-		return clients, err
+		return err
 	}
 	defer clientsStmt.Close()
 
-	var rows *sql.Rows
-	if limit == 0 {
-		rows, err = clientsStmt.Query(offset, nil)
-	} else {
-		rows, err = clientsStmt.Query(offset, limit)
-	}
+	rows, err := clientsStmt.QueryContext(ctx, builder.args...)
 	if err != nil {
 		log.Println(err)
-		return clients, err
+		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 
-		var (
-			id   int
-			name string
-		)
-
-		err := rows.Scan(&id, &name)
-		clients = append(clients, models.Client{
-			Id:   id,
-			Name: name,
-		})
+		client, err := scanClient(rows)
 		if err != nil {
 			log.Println(err)
-			return clients, err
+			return err
+		}
+		err = each(client)
+		if err != nil {
+			return err
 		}
 	}
 	err = rows.Err()
 	if err != nil {
 		log.Println(err)
-		return clients, err
+		return err
 	}
 
-	return clients, nil
+	return nil
 }
 
-func (pg *postgres) GetClientById(ctx context.Context, id int) (models.Client, error) {
+func (pg *postgres) CountClients(ctx context.Context, query models.ListQuery) (int64, error) {
+
+	builder := &sqlBuilder{args: []any{query.IncludeDeleted}, columns: clientColumnsByField}
+
+	where, err := builder.where(query.Filter)
+	if err != nil {
+		return 0, err
+	}
+
+	countStmt, err := pg.db.Prepare("SELECT count(*) FROM clients WHERE ($1 OR deleted_at IS NULL) AND " + where)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	defer countStmt.Close()
 
-	var name string
+	var count int64
+	err = countStmt.QueryRow(builder.args...).Scan(&count)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
 
-	clientByIdStmt, err := pg.db.Prepare("SELECT name FROM clients WHERE id = $1")
+	return count, nil
+}
+
+func (pg *postgres) GetClientById(ctx context.Context, id int, includeDeleted bool) (models.Client, error) {
+
+	clientByIdStmt, err := pg.db.Prepare("SELECT " + clientColumns + " FROM clients WHERE id = $1 AND ($2 OR deleted_at IS NULL)")
 	if err != nil {
 		log.Println(err)
 		return models.Client{}, err
 	}
 	defer clientByIdStmt.Close()
 
-	err = clientByIdStmt.QueryRow(id).Scan(&name)
+	client, err := scanClient(clientByIdStmt.QueryRow(id, includeDeleted))
 	if err != nil {
 
 		if err == sql.ErrNoRows {
-			return models.Client{}, errors.New(fmt.Sprintf("No client found by id %d\n", id))
+			return models.Client{}, apperrors.NotFound("No client found by id %d", id)
 		} else {
 			log.Println(err)
 			return models.Client{}, err
 		}
 	}
-	return models.Client{Id: id, Name: name}, nil
+	return client, nil
 }
 
 func (pg *postgres) CreateClient(ctx context.Context, newClient models.Client) (models.Client, error) {
+	return createClient(pg.db, newClient)
+}
 
-	insertClientStmt, err := pg.db.Prepare("INSERT INTO clients(name) VALUES($1) returning *")
+func createClient(db preparer, newClient models.Client) (models.Client, error) {
+
+	insertClientStmt, err := db.Prepare(
+		"INSERT INTO clients(name, email, phone, address, tax_id, status, tags, custom_fields)" +
+			" VALUES($1, $2, $3, $4, $5, $6, $7, $8) returning " + clientColumns,
+	)
 	if err != nil {
 		return models.Client{}, err
 	}
 	defer insertClientStmt.Close()
 
-	lastId := 0
-	name := ""
-	err = insertClientStmt.QueryRow(newClient.Name).Scan(&lastId, &name)
+	customFields, err := json.Marshal(newClient.WithDefaults().CustomFields)
+	if err != nil {
+		return models.Client{}, err
+	}
+
+	client, err := scanClient(insertClientStmt.QueryRow(
+		newClient.Name,
+		newClient.Email,
+		newClient.Phone,
+		newClient.Address,
+		newClient.TaxId,
+		newClient.Status,
+		pq.Array(newClient.Tags),
+		customFields,
+	))
 	if err != nil {
 		log.Println(err)
 		return models.Client{}, err
 	}
 
-	return models.Client{Id: lastId, Name: name}, nil
+	return client, nil
 }
 
 func (pg *postgres) UpdateClient(ctx context.Context, client models.Client) error {
+	return updateClient(pg.db, client)
+}
+
+// dbError classifies driver errors the caller can act on, other errors are returned as they are.
+func dbError(err error) error {
+
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound("not found")
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		// unique and foreign key violations
+		case "23505", "23503":
+			return apperrors.Wrap(apperrors.KindConflict, errors.New(pqErr.Detail))
+		}
+	}
+	return err
+}
 
-	updateClientStmt, err := pg.db.Prepare("UPDATE clients SET name = $1 WHERE id = $2")
+type preparer interface {
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// updateClient runs on the database or within a transaction.
+func updateClient(db preparer, client models.Client) error {
+
+	updateClientStmt, err := db.Prepare(
+		"UPDATE clients SET name = $1, email = $2, phone = $3, address = $4, tax_id = $5, status = $6, tags = $7, custom_fields = $8," +
+			" updated_at = now(), version = version + 1" +
+			" WHERE id = $9 AND deleted_at IS NULL AND ($10 = 0 OR version = $10)",
+	)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 	defer updateClientStmt.Close()
 
-	res, err := updateClientStmt.Exec(client.Name, client.Id)
+	customFields, err := json.Marshal(client.WithDefaults().CustomFields)
+	if err != nil {
+		return err
+	}
+
+	res, err := updateClientStmt.Exec(
+		client.Name,
+		client.Email,
+		client.Phone,
+		client.Address,
+		client.TaxId,
+		client.Status,
+		pq.Array(client.Tags),
+		customFields,
+		client.Id,
+		client.Version,
+	)
 	if err != nil {
 		log.Println(err)
 		return err
@@ -167,70 +326,183 @@ func (pg *postgres) UpdateClient(ctx context.Context, client models.Client) erro
 		return err
 	}
 	if rowCount == 0 {
-		return errors.New("no rows affected")
+		return missingOrConflict(db, client.Id)
 	}
 
 	return nil
 }
 
-func (pg *postgres) DeleteClient(ctx context.Context, id int) error {
+// missingOrConflict tells why a versioned change of a client affected no row.
+func missingOrConflict(db preparer, id int) error {
 
-	deleteClientStmt, err := pg.db.Prepare("DELETE FROM clients WHERE id = $1")
+	existsStmt, err := db.Prepare("SELECT EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)")
 	if err != nil {
 		log.Println(err)
 		return err
 	}
-	defer deleteClientStmt.Close()
+	defer existsStmt.Close()
 
-	res, err := deleteClientStmt.Exec(id)
+	var exists bool
+	err = existsStmt.QueryRow(id).Scan(&exists)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
-	rowCount, err := res.RowsAffected()
+	if exists {
+		return interfaces.ErrVersionConflict
+	}
+	return apperrors.NotFound("no rows affected")
+}
+
+// clientDependents are the tables whose rows are soft-deleted and restored together with their client.
+var clientDependents = []string{"contacts", "activities", "attachments"}
+
+func (pg *postgres) DeleteClient(ctx context.Context, id int, version int) error {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
-	if rowCount == 0 {
-		return errors.New("no rows affected")
+	defer tx.Rollback()
+
+	err = deleteClient(tx, id, version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteClient soft-deletes the client and its dependents with the same timestamp.
+func deleteClient(tx *sql.Tx, id int, version int) error {
+
+	var deletedAt time.Time
+	err := tx.QueryRow(
+		"UPDATE clients SET deleted_at = now(), version = version + 1"+
+			" WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2) returning deleted_at",
+		id, version,
+	).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return missingOrConflict(tx, id)
+		}
+		log.Println(err)
+		return err
+	}
+
+	for _, table := range clientDependents {
+		_, err = tx.Exec("UPDATE "+table+" SET deleted_at = $2 WHERE client_id = $1 AND deleted_at IS NULL", id, deletedAt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (pg *postgres) List(ctx context.Context, offset int, limit int) ([]models.User, error) {
+func (pg *postgres) RestoreClient(ctx context.Context, id int) (models.Client, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return models.Client{}, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	err = tx.QueryRow("SELECT deleted_at FROM clients WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", id).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Client{}, apperrors.NotFound("No deleted client found by id %d", id)
+		}
+		log.Println(err)
+		return models.Client{}, err
+	}
+
+	// only rows deleted together with the client come back, not the ones deleted before it
+	for _, table := range clientDependents {
+		_, err = tx.Exec("UPDATE "+table+" SET deleted_at = NULL WHERE client_id = $1 AND deleted_at = $2", id, deletedAt)
+		if err != nil {
+			log.Println(err)
+			return models.Client{}, err
+		}
+	}
+
+	client, err := scanClient(tx.QueryRow("UPDATE clients SET deleted_at = NULL, version = version + 1 WHERE id = $1 returning "+clientColumns, id))
+	if err != nil {
+		log.Println(err)
+		return models.Client{}, err
+	}
+
+	return client, tx.Commit()
+}
+
+func (pg *postgres) PurgeDeletedClients(ctx context.Context, deletedBefore time.Time) (int64, error) {
+
+	purgeClientsStmt, err := pg.db.Prepare("DELETE FROM clients WHERE deleted_at < $1")
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	defer purgeClientsStmt.Close()
+
+	res, err := purgeClientsStmt.Exec(deletedBefore)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (pg *postgres) List(ctx context.Context, query models.ListQuery) ([]models.User, error) {
 	var users []models.User
 
-	usersStmt, err := pg.db.Prepare("SELECT * FROM users ORDER BY id LIMIT $2 OFFSET $1")
+	builder := &sqlBuilder{args: []any{query.Offset, nil, query.IncludeDeleted}, columns: userColumnsByField}
+	if query.Limit != 0 {
+		builder.args[1] = query.Limit
+	}
+
+	where, err := builder.where(query.Filter)
+	if err != nil {
+		return users, err
+	}
+	orderBy, err := builder.orderBy(query.Sort)
+	if err != nil {
+		return users, err
+	}
+
+	usersStmt, err := pg.db.Prepare("SELECT id, name, email, deleted_at FROM users WHERE ($3 OR deleted_at IS NULL) AND " + where + orderBy + " LIMIT $2 OFFSET $1")
 	if err != nil {
 		log.Println(err)
 		return users, err
 	}
 	defer usersStmt.Close()
 
-	var rows *sql.Rows
-	if limit == 0 {
-		rows, err = usersStmt.Query(offset, nil)
-	} else {
-		rows, err = usersStmt.Query(offset, limit)
-	}
+	rows, err := usersStmt.Query(builder.args...)
 	if err != nil {
 		log.Println(err)
 		return users, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 
 		var (
-			id   int
-			name string
+			id        int
+			name      string
+			email     string
+			deletedAt sql.NullTime
 		)
 
-		err := rows.Scan(&id, &name)
+		err := rows.Scan(&id, &name, &email, &deletedAt)
 		users = append(users, models.User{
-			Id:   id,
-			Name: name,
+			Id:        id,
+			Name:      name,
+			Email:     email,
+			DeletedAt: nullTimeToPtr(deletedAt),
 		})
 		if err != nil {
 			log.Println(err)
@@ -246,28 +518,32 @@ func (pg *postgres) List(ctx context.Context, offset int, limit int) ([]models.U
 	return users, nil
 }
 
-func (pg *postgres) ById(ctx context.Context, id int) (models.User, error) {
+func (pg *postgres) ById(ctx context.Context, id int, includeDeleted bool) (models.User, error) {
 
-	var name string
+	var (
+		name      string
+		email     string
+		deletedAt sql.NullTime
+	)
 
-	userByIdStmt, err := pg.db.Prepare("SELECT name FROM user WHERE id = $1")
+	userByIdStmt, err := pg.db.Prepare("SELECT name, email, deleted_at FROM users WHERE id = $1 AND ($2 OR deleted_at IS NULL)")
 	if err != nil {
 		log.Println(err)
 		return models.User{}, err
 	}
 	defer userByIdStmt.Close()
 
-	err = userByIdStmt.QueryRow(id).Scan(&name)
+	err = userByIdStmt.QueryRow(id, includeDeleted).Scan(&name, &email, &deletedAt)
 	if err != nil {
 
 		if err == sql.ErrNoRows {
-			return models.User{}, errors.New(fmt.Sprintf("No user found by id %d", id))
+			return models.User{}, apperrors.NotFound("No user found by id %d", id)
 		} else {
 			log.Println(err)
 			return models.User{}, err
 		}
 	}
-	return models.User{Id: id, Name: name}, nil
+	return models.User{Id: id, Name: name, Email: email, DeletedAt: nullTimeToPtr(deletedAt)}, nil
 }
 
 func (pg *postgres) ByEmail(ctx context.Context, email string) (models.User, error) {
@@ -275,7 +551,7 @@ func (pg *postgres) ByEmail(ctx context.Context, email string) (models.User, err
 	var id int
 	var password string
 
-	userByEmailStmt, err := pg.db.Prepare("SELECT id, password FROM users WHERE email = $1")
+	userByEmailStmt, err := pg.db.Prepare("SELECT id, password FROM users WHERE email = $1 AND deleted_at IS NULL")
 	if err != nil {
 		log.Println(err)
 		return models.User{}, err
@@ -317,7 +593,7 @@ func (pg *postgres) CreateUser(ctx context.Context, newUser models.User) (models
 }
 
 func (pg *postgres) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
-	updateClientStmt, err := pg.db.Prepare("UPDATE users SET name = $1  WHERE id = $2")
+	updateClientStmt, err := pg.db.Prepare("UPDATE users SET name = $1  WHERE id = $2 AND deleted_at IS NULL")
 	if err != nil {
 		log.Println(err)
 		return models.User{}, err
@@ -336,23 +612,23 @@ func (pg *postgres) UpdateUser(ctx context.Context, user models.User) (models.Us
 		return models.User{}, err
 	}
 	if rowCount == 0 {
-		return models.User{}, errors.New("no rows affected")
+		return models.User{}, apperrors.NotFound("no rows affected")
 	}
 
-	userUpdated, _ := pg.ById(ctx, user.Id)
+	userUpdated, _ := pg.ById(ctx, user.Id, false)
 	return userUpdated, nil
 }
 
 func (pg *postgres) DeleteUser(ctx context.Context, id int) (models.User, error) {
 
-	deleteUserStmt, err := pg.db.Prepare("DELETE FROM users WHERE id = $1")
+	deleteUserStmt, err := pg.db.Prepare("UPDATE users SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL")
 	if err != nil {
 		log.Println(err)
 		return models.User{}, err
 	}
 	defer deleteUserStmt.Close()
 
-	user, err := pg.ById(ctx, id)
+	user, err := pg.ById(ctx, id, false)
 
 	res, err := deleteUserStmt.Exec(id)
 	if err != nil {
@@ -365,15 +641,123 @@ func (pg *postgres) DeleteUser(ctx context.Context, id int) (models.User, error)
 		return models.User{}, err
 	}
 	if rowCount == 0 {
-		return models.User{}, errors.New("no rows affected")
+		return models.User{}, apperrors.NotFound("no rows affected")
+	}
+
+	return user, nil
+}
+
+func (pg *postgres) RestoreUser(ctx context.Context, id int) (models.User, error) {
+
+	restoreUserStmt, err := pg.db.Prepare("UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL returning id, name, email")
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+	defer restoreUserStmt.Close()
+
+	var user models.User
+	err = restoreUserStmt.QueryRow(id).Scan(&user.Id, &user.Name, &user.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, apperrors.NotFound("No deleted user found by id %d", id)
+		}
+		log.Println(err)
+		return models.User{}, err
 	}
 
 	return user, nil
 }
 
+func (pg *postgres) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM userroles WHERE userid IN (SELECT id FROM users WHERE deleted_at < $1)", deletedBefore)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	res, err := tx.Exec("DELETE FROM users WHERE deleted_at < $1", deletedBefore)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return rowCount, tx.Commit()
+}
+
+// UpdateRoles replaces the roles of the user with the given ones, only their ids are read.
 func (pg *postgres) UpdateRoles(ctx context.Context, user models.User, roles []models.Role) (models.User, error) {
-	//TODO implement me
-	panic("implement me")
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	var found bool
+	err = tx.QueryRow("SELECT true FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", user.Id).Scan(&found)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.User{}, apperrors.NotFound("No user found by id %d", user.Id)
+		}
+		log.Println(err)
+		return models.User{}, err
+	}
+
+	_, err = tx.Exec("DELETE FROM userroles WHERE userid = $1", user.Id)
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+
+	assigned := map[int]bool{}
+	for _, role := range roles {
+		if assigned[role.Id] {
+			continue
+		}
+		assigned[role.Id] = true
+
+		res, err := tx.Exec("INSERT INTO userroles(userid, roleid) SELECT $1, id FROM roles WHERE id = $2", user.Id, role.Id)
+		if err != nil {
+			log.Println(err)
+			return models.User{}, err
+		}
+		rowCount, err := res.RowsAffected()
+		if err != nil {
+			log.Println(err)
+			return models.User{}, err
+		}
+		if rowCount == 0 {
+			return models.User{}, apperrors.NotFound("No role found by id %d", role.Id)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(err)
+		return models.User{}, err
+	}
+
+	updated, err := pg.ById(ctx, user.Id, false)
+	if err != nil {
+		return models.User{}, err
+	}
+	updated.Roles, err = pg.UserRoles(ctx, user.Id)
+	return updated, err
 }
 
 func (pg *postgres) GetAllUserGrants(ctx context.Context, userId int) (grants []models.Grant, err error) {
@@ -432,9 +816,10 @@ func (pg *postgres) GetAllUserGrants(ctx context.Context, userId int) (grants []
 func (pg *postgres) CheckUserGrant(ctx context.Context, userId int, table string, operation string) (found bool, err error) {
 
 	sqlString := "SELECT true found FROM userroles ur " +
+		" JOIN users u ON ur.userid = u.id" +
 		" JOIN roles r ON ur.roleid = r.id" +
 		" JOIN grants g on r.id = g.roleid" +
-		" WHERE ur.userid = $1 AND g.ontable = $2"
+		" WHERE ur.userid = $1 AND g.ontable = $2 AND u.deleted_at IS NULL"
 
 	sqlString += " AND \"" + operation + "\" = true"
 
@@ -454,3 +839,26 @@ func (pg *postgres) CheckUserGrant(ctx context.Context, userId int, table string
 
 	return found, nil
 }
+
+func (pg *postgres) CheckUserRole(ctx context.Context, userId int, role string) (found bool, err error) {
+
+	roleStmt, err := pg.db.Prepare(
+		"SELECT EXISTS (SELECT 1 FROM userroles ur" +
+			" JOIN users u ON ur.userid = u.id" +
+			" JOIN roles r ON ur.roleid = r.id" +
+			" WHERE ur.userid = $1 AND r.name = $2 AND u.deleted_at IS NULL)",
+	)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+	defer roleStmt.Close()
+
+	err = roleStmt.QueryRow(userId, role).Scan(&found)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return found, nil
+}
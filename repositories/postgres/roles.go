@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testApplication/models"
+)
+
+const roleColumns = "r.id, COALESCE(r.name, ''), g.ontable, g.read, g.\"create\", g.update, g.delete"
+
+// scanRoles reads rows of roleColumns ordered by role, a role without grants has a single row of NULL grant columns.
+func scanRoles(rows *sql.Rows) ([]models.Role, error) {
+	var roles []models.Role
+
+	for rows.Next() {
+		var (
+			role                         models.Role
+			table                        sql.NullString
+			read, create, update, delete sql.NullBool
+		)
+		err := rows.Scan(&role.Id, &role.Name, &table, &read, &create, &update, &delete)
+		if err != nil {
+			log.Println(err)
+			return roles, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Id != role.Id {
+			roles = append(roles, role)
+		}
+		if table.Valid {
+			last := &roles[len(roles)-1]
+			last.Grants = append(last.Grants, models.Grant{
+				Table:  table.String,
+				Read:   read.Bool,
+				Create: create.Bool,
+				Update: update.Bool,
+				Delete: delete.Bool,
+			})
+		}
+	}
+	err := rows.Err()
+	if err != nil {
+		log.Println(err)
+		return roles, err
+	}
+
+	return roles, nil
+}
+
+func (pg *postgres) Roles(ctx context.Context) ([]models.Role, error) {
+
+	rolesStmt, err := pg.db.Prepare(
+		"SELECT " + roleColumns + " FROM roles r" +
+			" LEFT JOIN grants g ON g.roleid = r.id" +
+			" ORDER BY r.id, g.ontable",
+	)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rolesStmt.Close()
+
+	rows, err := rolesStmt.Query()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+func (pg *postgres) UserRoles(ctx context.Context, userId int) ([]models.Role, error) {
+
+	rolesStmt, err := pg.db.Prepare(
+		"SELECT " + roleColumns + " FROM userroles ur" +
+			" JOIN roles r ON ur.roleid = r.id" +
+			" LEFT JOIN grants g ON g.roleid = r.id" +
+			" WHERE ur.userid = $1" +
+			" ORDER BY r.id, g.ontable",
+	)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rolesStmt.Close()
+
+	rows, err := rolesStmt.Query(userId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"testApplication/models"
+	"testApplication/search"
+)
+
+// trailingColumns scans columns selected after the ones the wrapped scanner knows about.
+type trailingColumns struct {
+	row  rowScanner
+	dest []any
+}
+
+func (t trailingColumns) Scan(dest ...any) error {
+	return t.row.Scan(append(dest, t.dest...)...)
+}
+
+// setSimilarityThreshold makes the <% operator, which can use the trigram index, match the words
+// search.Highlight marks, its threshold is only settable per session or transaction.
+func setSimilarityThreshold(tx *sql.Tx) error {
+	_, err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)", strconv.FormatFloat(search.MinSimilarity, 'f', -1, 64))
+	return err
+}
+
+func (pg *postgres) SearchClients(ctx context.Context, terms []string, limit int) ([]models.ClientSearchResult, error) {
+
+	results := []models.ClientSearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	// terms are plain words, so they can be turned into prefix queries without escaping
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+
+	tx, err := pg.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	defer tx.Rollback()
+
+	err = setSimilarityThreshold(tx)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+
+	searchStmt, err := tx.Prepare(
+		"SELECT " + clientColumns + ", ts_rank(search_vector, tsquery) + word_similarity($1, name) AS rank" +
+			" FROM clients, to_tsquery('simple', $2) tsquery" +
+			" WHERE deleted_at IS NULL AND (search_vector @@ tsquery OR $1 <% name)" +
+			" ORDER BY rank DESC, id LIMIT $3",
+	)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	defer searchStmt.Close()
+
+	rows, err := searchStmt.Query(strings.Join(terms, " "), strings.Join(prefixes, " & "), limit)
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var rank float64
+		client, err := scanClient(trailingColumns{row: rows, dest: []any{&rank}})
+		if err != nil {
+			log.Println(err)
+			return results, err
+		}
+		results = append(results, search.ClientResult(client, terms, rank))
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return results, err
+	}
+
+	return results, nil
+}
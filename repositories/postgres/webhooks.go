@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"log"
+	"testApplication/apperrors"
+	"testApplication/models"
+	"time"
+)
+
+const webhookColumns = "id, url, events, secret, active, created_by, created_at"
+
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+
+	var webhook models.Webhook
+	err := row.Scan(
+		&webhook.Id,
+		&webhook.Url,
+		pq.Array(&webhook.Events),
+		&webhook.Secret,
+		&webhook.Active,
+		&webhook.CreatedBy,
+		&webhook.CreatedAt,
+	)
+
+	return webhook, err
+}
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at," +
+	" last_status_code, last_error, created_at, delivered_at"
+
+func scanDelivery(row rowScanner) (models.WebhookDelivery, error) {
+
+	var delivery models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&delivery.Id,
+		&delivery.WebhookId,
+		&delivery.EventId,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	delivery.Payload = payload
+
+	return delivery, err
+}
+
+func (pg *postgres) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	webhooksStmt, err := pg.db.Prepare("SELECT " + webhookColumns + " FROM webhooks ORDER BY id")
+	if err != nil {
+		log.Println(err)
+		return webhooks, err
+	}
+	defer webhooksStmt.Close()
+
+	rows, err := webhooksStmt.Query()
+	if err != nil {
+		log.Println(err)
+		return webhooks, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			log.Println(err)
+			return webhooks, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return webhooks, err
+	}
+
+	return webhooks, nil
+}
+
+func (pg *postgres) GetWebhookById(ctx context.Context, id int) (models.Webhook, error) {
+
+	webhookByIdStmt, err := pg.db.Prepare("SELECT " + webhookColumns + " FROM webhooks WHERE id = $1")
+	if err != nil {
+		log.Println(err)
+		return models.Webhook{}, err
+	}
+	defer webhookByIdStmt.Close()
+
+	webhook, err := scanWebhook(webhookByIdStmt.QueryRow(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Webhook{}, apperrors.NotFound("No webhook found by id %d", id)
+		}
+		log.Println(err)
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (pg *postgres) CreateWebhook(ctx context.Context, newWebhook models.Webhook) (models.Webhook, error) {
+
+	insertWebhookStmt, err := pg.db.Prepare(
+		"INSERT INTO webhooks(url, events, secret, active, created_by) VALUES($1, $2, $3, $4, $5) returning " + webhookColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.Webhook{}, err
+	}
+	defer insertWebhookStmt.Close()
+
+	webhook, err := scanWebhook(insertWebhookStmt.QueryRow(
+		newWebhook.Url, pq.Array(newWebhook.Events), newWebhook.Secret, newWebhook.Active, newWebhook.CreatedBy,
+	))
+	if err != nil {
+		log.Println(err)
+		return models.Webhook{}, dbError(err)
+	}
+
+	return webhook, nil
+}
+
+func (pg *postgres) UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+
+	updateWebhookStmt, err := pg.db.Prepare(
+		"UPDATE webhooks SET url = $1, events = $2, active = $3 WHERE id = $4 returning " + webhookColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.Webhook{}, err
+	}
+	defer updateWebhookStmt.Close()
+
+	updatedWebhook, err := scanWebhook(updateWebhookStmt.QueryRow(webhook.Url, pq.Array(webhook.Events), webhook.Active, webhook.Id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Webhook{}, apperrors.NotFound("No webhook found by id %d", webhook.Id)
+		}
+		log.Println(err)
+		return models.Webhook{}, err
+	}
+
+	return updatedWebhook, nil
+}
+
+func (pg *postgres) DeleteWebhook(ctx context.Context, id int) error {
+
+	deleteWebhookStmt, err := pg.db.Prepare("DELETE FROM webhooks WHERE id = $1")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer deleteWebhookStmt.Close()
+
+	res, err := deleteWebhookStmt.Exec(id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rowCount == 0 {
+		return apperrors.NotFound("No webhook found by id %d", id)
+	}
+
+	return nil
+}
+
+func (pg *postgres) EnqueueDeliveries(ctx context.Context, eventId string, eventType string, payload []byte) error {
+
+	enqueueStmt, err := pg.db.Prepare(
+		"INSERT INTO webhook_deliveries(webhook_id, event_id, event_type, payload)" +
+			" SELECT id, $1, $2, $3 FROM webhooks WHERE active AND $2 = ANY(events)",
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer enqueueStmt.Close()
+
+	_, err = enqueueStmt.Exec(eventId, eventType, payload)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (pg *postgres) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	claimStmt, err := pg.db.Prepare(
+		"UPDATE webhook_deliveries SET next_attempt_at = now() + $2 * interval '1 millisecond'" +
+			" WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= now()" +
+			" ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)" +
+			" returning " + deliveryColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+	defer claimStmt.Close()
+
+	rows, err := claimStmt.Query(limit, lease.Milliseconds())
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			log.Println(err)
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func (pg *postgres) RecordAttempt(ctx context.Context, delivery models.WebhookDelivery) error {
+
+	recordStmt, err := pg.db.Prepare(
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4," +
+			" last_error = $5, delivered_at = $6 WHERE id = $7",
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer recordStmt.Close()
+
+	_, err = recordStmt.Exec(
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.DeliveredAt, delivery.Id,
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (pg *postgres) GetDeliveries(ctx context.Context, webhookId int, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	deliveriesStmt, err := pg.db.Prepare(
+		"SELECT " + deliveryColumns + " FROM webhook_deliveries" +
+			" WHERE webhook_id = $1 AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3",
+	)
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+	defer deliveriesStmt.Close()
+
+	rows, err := deliveriesStmt.Query(webhookId, status, limit)
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			log.Println(err)
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	err = rows.Err()
+	if err != nil {
+		log.Println(err)
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func (pg *postgres) RetryDelivery(ctx context.Context, webhookId int, id int64) (models.WebhookDelivery, error) {
+
+	retryStmt, err := pg.db.Prepare(
+		"UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now()" +
+			" WHERE webhook_id = $1 AND id = $2 AND status = 'dead' returning " + deliveryColumns,
+	)
+	if err != nil {
+		log.Println(err)
+		return models.WebhookDelivery{}, err
+	}
+	defer retryStmt.Close()
+
+	delivery, err := scanDelivery(retryStmt.QueryRow(webhookId, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.WebhookDelivery{}, apperrors.NotFound("No dead delivery found by id %d", id)
+		}
+		log.Println(err)
+		return models.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+func (pg *postgres) PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+
+	res, err := pg.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE status = 'delivered' AND delivered_at < $1", deliveredBefore)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	rowCount, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return rowCount, nil
+}
@@ -0,0 +1,55 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Purger removes soft-deleted rows that were deleted before the given moment
+// and reports how many rows were removed.
+type Purger func(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+type Task struct {
+	Name  string
+	Purge Purger
+}
+
+// Run purges soft-deleted rows older than period every interval until ctx is cancelled.
+// Tasks run in the given order, so dependents go before the rows they reference.
+func Run(ctx context.Context, period time.Duration, interval time.Duration, tasks []Task) {
+
+	if period <= 0 || interval <= 0 {
+		log.Println("retention: period or interval is not set, purging disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purge(ctx, period, tasks)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purge(ctx context.Context, period time.Duration, tasks []Task) {
+
+	deletedBefore := time.Now().Add(-period)
+
+	for _, task := range tasks {
+		count, err := task.Purge(ctx, deletedBefore)
+		if err != nil {
+			log.Printf("retention: purging %s failed: %s", task.Name, err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("retention: purged %d %s deleted before %s", count, task.Name, deletedBefore.Format(time.RFC3339))
+		}
+	}
+}
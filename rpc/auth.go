@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"strings"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
+	"testApplication/redis"
+	"testApplication/rpc/pb"
+)
+
+type userIdKey struct{}
+
+// grant is the table and operation a method needs, the same grants the REST routes check.
+type grant struct {
+	table     string
+	operation string
+}
+
+var methodGrants = map[string]grant{
+	pb.ClientService_GetClient_FullMethodName:    {"clients", "read"},
+	pb.ClientService_ListClients_FullMethodName:  {"clients", "read"},
+	pb.ClientService_CreateClient_FullMethodName: {"clients", "create"},
+	pb.ClientService_UpdateClient_FullMethodName: {"clients", "update"},
+	pb.ClientService_DeleteClient_FullMethodName: {"clients", "delete"},
+	pb.UserService_GetUser_FullMethodName:        {"users", "read"},
+	pb.UserService_ListUsers_FullMethodName:      {"users", "read"},
+	pb.UserService_CreateUser_FullMethodName:     {"users", "create"},
+	pb.UserService_UpdateUser_FullMethodName:     {"users", "update"},
+	pb.UserService_DeleteUser_FullMethodName:     {"users", "delete"},
+}
+
+// openMethods are the method prefixes callable without a token, health checks and reflection.
+var openMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// authenticator checks the bearer token of a call against Redis and the grant its method needs.
+// Methods that are neither open nor have a grant are denied.
+type authenticator struct {
+	redisConn *redis.Connection
+	userRepo  interfaces.UserRepo
+}
+
+func (auth *authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+
+	for _, prefix := range openMethods {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+	required, ok := methodGrants[method]
+	if !ok {
+		log.Printf("grpc: %s has no grant, denied", method)
+		return ctx, apperrors.Forbidden("method %s is not allowed", method)
+	}
+
+	token := bearerToken(ctx)
+	if token == "" {
+		return ctx, apperrors.New(apperrors.KindUnauthorized, "empty token")
+	}
+	userId, err := auth.redisConn.CheckToken(ctx, token)
+	if err != nil {
+		if err == redis.ErrUnauthorized {
+			return ctx, apperrors.New(apperrors.KindUnauthorized, "invalid or expired token")
+		}
+		return ctx, err
+	}
+
+	allowed, err := auth.userRepo.CheckUserGrant(ctx, userId, required.table, required.operation)
+	if err != nil {
+		return ctx, err
+	}
+	if !allowed {
+		log.Printf("grpc: %s denied for user id: %d", method, userId)
+		return ctx, apperrors.Forbidden("not allowed to %s %s", required.operation, required.table)
+	}
+
+	return context.WithValue(ctx, userIdKey{}, userId), nil
+}
+
+// includeDeleted checks the delete grant a caller needs to see soft deleted rows of table.
+func (auth *authenticator) includeDeleted(ctx context.Context, table string, requested bool) (bool, error) {
+
+	if !requested {
+		return false, nil
+	}
+	userId, _ := ctx.Value(userIdKey{}).(int)
+	allowed, err := auth.userRepo.CheckUserGrant(ctx, userId, table, "delete")
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		return false, apperrors.Forbidden("not allowed to view deleted rows")
+	}
+	return true, nil
+}
+
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return token
+		}
+	}
+	return ""
+}
+
+func (auth *authenticator) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	ctx, err := auth.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+// authorizedStream carries the context holding the caller to the stream handler.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authorizedStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (auth *authenticator) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	ctx, err := auth.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return statusError(err)
+	}
+	err = handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+	if err != nil {
+		return statusError(err)
+	}
+	return nil
+}
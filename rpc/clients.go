@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/query"
+	"testApplication/rpc/pb"
+	"testApplication/validation"
+)
+
+type clientService struct {
+	pb.UnimplementedClientServiceServer
+	repo             interfaces.ClientRepo
+	customFieldsRepo interfaces.CustomFieldRepo
+	auth             *authenticator
+}
+
+func (service *clientService) GetClient(ctx context.Context, req *pb.GetClientRequest) (*pb.Client, error) {
+
+	includeDeleted, err := service.auth.includeDeleted(ctx, "clients", req.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	client, err := service.repo.GetClientById(ctx, int(req.Id), includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return clientMessage(client)
+}
+
+func (service *clientService) ListClients(req *pb.ListClientsRequest, stream pb.ClientService_ListClientsServer) error {
+
+	ctx := stream.Context()
+	includeDeleted, err := service.auth.includeDeleted(ctx, "clients", req.IncludeDeleted)
+	if err != nil {
+		return err
+	}
+	definitions, err := service.customFieldsRepo.GetCustomFields(ctx)
+	if err != nil {
+		return err
+	}
+
+	schema := validation.ClientSchema(definitions)
+	filter, err := query.ParseFilter(req.Filter, schema)
+	if err != nil {
+		return badRequest(err)
+	}
+	sorts, err := query.ParseSort(req.Sort, schema)
+	if err != nil {
+		return badRequest(err)
+	}
+
+	return service.repo.ExportClients(ctx, models.ListQuery{
+		IncludeDeleted: includeDeleted,
+		Filter:         filter,
+		Sort:           sorts,
+	}, func(client models.Client) error {
+		message, err := clientMessage(client)
+		if err != nil {
+			return err
+		}
+		return stream.Send(message)
+	})
+}
+
+func (service *clientService) CreateClient(ctx context.Context, req *pb.CreateClientRequest) (*pb.Client, error) {
+
+	input := clientInput(req.Client)
+	err := validation.Struct(input)
+	if err != nil {
+		return nil, err
+	}
+	client := input.Model()
+	err = service.validate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err = service.repo.CreateClient(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return clientMessage(client)
+}
+
+func (service *clientService) UpdateClient(ctx context.Context, req *pb.UpdateClientRequest) (*pb.Client, error) {
+
+	input := dto.ClientUpdateInput{Id: int(req.Id), Version: int(req.Version), ClientInput: clientInput(req.Client)}
+	err := validation.Struct(input)
+	if err != nil {
+		return nil, err
+	}
+	client := input.Model()
+	err = service.validate(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.repo.UpdateClient(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	client, err = service.repo.GetClientById(ctx, client.Id, false)
+	if err != nil {
+		return nil, err
+	}
+	return clientMessage(client)
+}
+
+func (service *clientService) DeleteClient(ctx context.Context, req *pb.DeleteClientRequest) (*pb.DeleteClientResponse, error) {
+
+	err := service.repo.DeleteClient(ctx, int(req.Id), int(req.Version))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteClientResponse{}, nil
+}
+
+func (service *clientService) validate(ctx context.Context, client models.Client) error {
+
+	definitions, err := service.customFieldsRepo.GetCustomFields(ctx)
+	if err != nil {
+		return err
+	}
+	return validation.Client(client, definitions)
+}
+
+func clientInput(message *pb.ClientInput) dto.ClientInput {
+	if message == nil {
+		return dto.ClientInput{}
+	}
+	return dto.ClientInput{
+		Name:         message.Name,
+		Email:        message.Email,
+		Phone:        message.Phone,
+		Address:      message.Address,
+		TaxId:        message.TaxId,
+		Status:       message.Status,
+		Tags:         message.Tags,
+		CustomFields: message.CustomFields.AsMap(),
+	}
+}
+
+func clientMessage(client models.Client) (*pb.Client, error) {
+
+	customFields, err := structpb.NewStruct(client.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Client{
+		Id:           int32(client.Id),
+		Name:         client.Name,
+		Email:        client.Email,
+		Phone:        client.Phone,
+		Address:      client.Address,
+		TaxId:        client.TaxId,
+		Status:       client.Status,
+		Tags:         client.Tags,
+		CustomFields: customFields,
+		CreatedAt:    timestamppb.New(client.CreatedAt),
+		UpdatedAt:    timestamppb.New(client.UpdatedAt),
+		DeletedAt:    timestampOrNil(client.DeletedAt),
+		Version:      int32(client.Version),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"sort"
+	"testApplication/apperrors"
+	"testApplication/validation"
+)
+
+var statusCodes = map[apperrors.Kind]codes.Code{
+	apperrors.KindBadRequest:           codes.InvalidArgument,
+	apperrors.KindUnauthorized:         codes.Unauthenticated,
+	apperrors.KindForbidden:            codes.PermissionDenied,
+	apperrors.KindNotFound:             codes.NotFound,
+	apperrors.KindConflict:             codes.AlreadyExists,
+	apperrors.KindPreconditionFailed:   codes.FailedPrecondition,
+	apperrors.KindPreconditionRequired: codes.FailedPrecondition,
+	apperrors.KindValidation:           codes.InvalidArgument,
+	apperrors.KindUnprocessable:        codes.InvalidArgument,
+	apperrors.KindUnsupportedMediaType: codes.InvalidArgument,
+	apperrors.KindTooLarge:             codes.ResourceExhausted,
+	apperrors.KindGone:                 codes.Unimplemented,
+}
+
+// statusError turns an application error into a gRPC status, rejected fields travel as BadRequest
+// details. Internal errors are logged and only reported as such.
+func statusError(err error) error {
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if fields, ok := err.(validation.FieldErrors); ok {
+		err = apperrors.Validation(fields)
+	}
+	appErr := apperrors.From(err)
+
+	code, ok := statusCodes[appErr.Kind]
+	if !ok {
+		log.Printf("grpc: %s", err)
+		return status.Error(codes.Internal, appErr.Error())
+	}
+
+	st := status.New(code, appErr.Error())
+	if len(appErr.Fields) == 0 {
+		return st.Err()
+	}
+
+	names := make([]string, 0, len(appErr.Fields))
+	for name := range appErr.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	details := &errdetails.BadRequest{}
+	for _, name := range names {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: name, Description: appErr.Fields[name]})
+	}
+	withDetails, detailsErr := st.WithDetails(details)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,44 @@
+// Package rpc serves the clients and users over gRPC next to the REST API, on the same repositories.
+package rpc
+
+//go:generate protoc -I proto --go_out=../.. --go_opt=module=testApplication --go-grpc_out=../.. --go-grpc_opt=module=testApplication clients.proto users.proto
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"testApplication/apperrors"
+	"testApplication/interfaces"
+	"testApplication/redis"
+	"testApplication/rpc/pb"
+)
+
+func NewServer(
+	clientRepo interfaces.ClientRepo,
+	customFieldsRepo interfaces.CustomFieldRepo,
+	userRepo interfaces.UserRepo,
+	redisConn *redis.Connection,
+) (*grpc.Server, error) {
+
+	auth := &authenticator{redisConn: redisConn, userRepo: userRepo}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.unary),
+		grpc.StreamInterceptor(auth.stream),
+	)
+
+	pb.RegisterClientServiceServer(server, &clientService{repo: clientRepo, customFieldsRepo: customFieldsRepo, auth: auth})
+	pb.RegisterUserServiceServer(server, &userService{repo: userRepo, auth: auth})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.ClientService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+
+	return server, nil
+}
+
+func badRequest(err error) error {
+	return apperrors.Wrap(apperrors.KindBadRequest, err)
+}
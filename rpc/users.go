@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testApplication/dto"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testApplication/pagination"
+	"testApplication/query"
+	"testApplication/rpc/pb"
+	"testApplication/validation"
+	"time"
+)
+
+type userService struct {
+	pb.UnimplementedUserServiceServer
+	repo interfaces.UserRepo
+	auth *authenticator
+}
+
+func (service *userService) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+
+	includeDeleted, err := service.auth.includeDeleted(ctx, "users", req.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	user, err := service.repo.ById(ctx, int(req.Id), includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+// ListUsers sends the users page by page, the user repository has no cursor to stream from.
+func (service *userService) ListUsers(req *pb.ListUsersRequest, stream pb.UserService_ListUsersServer) error {
+
+	ctx := stream.Context()
+	includeDeleted, err := service.auth.includeDeleted(ctx, "users", req.IncludeDeleted)
+	if err != nil {
+		return err
+	}
+
+	schema := query.UserSchema()
+	filter, err := query.ParseFilter(req.Filter, schema)
+	if err != nil {
+		return badRequest(err)
+	}
+	sorts, err := query.ParseSort(req.Sort, schema)
+	if err != nil {
+		return badRequest(err)
+	}
+
+	for offset := 0; ; offset += pagination.MaxLimit {
+		users, err := service.repo.List(ctx, models.ListQuery{
+			Offset:         offset,
+			Limit:          pagination.MaxLimit,
+			IncludeDeleted: includeDeleted,
+			Filter:         filter,
+			Sort:           sorts,
+		})
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			err = stream.Send(userMessage(user))
+			if err != nil {
+				return err
+			}
+		}
+		if len(users) < pagination.MaxLimit {
+			return nil
+		}
+	}
+}
+
+func (service *userService) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+
+	input := dto.UserInput{Name: req.Name, Email: req.Email, Password: req.Password}
+	err := validation.Struct(input)
+	if err != nil {
+		return nil, err
+	}
+
+	user := input.Model()
+	pass, err := bcrypt.GenerateFromPassword([]byte(user.Password), 14)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = string(pass)
+
+	user, err = service.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+func (service *userService) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+
+	input := dto.UserUpdateInput{Id: int(req.Id), Name: req.Name}
+	err := validation.Struct(input)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := service.repo.UpdateUser(ctx, input.Model())
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+func (service *userService) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.User, error) {
+
+	user, err := service.repo.DeleteUser(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+// userMessage leaves the password hash out.
+func userMessage(user models.User) *pb.User {
+
+	message := &pb.User{
+		Id:        int32(user.Id),
+		Email:     user.Email,
+		Name:      user.Name,
+		DeletedAt: timestampOrNil(user.DeletedAt),
+	}
+	for _, role := range user.Roles {
+		roleMessage := &pb.Role{Id: int32(role.Id), Name: role.Name}
+		for _, grant := range role.Grants {
+			roleMessage.Grants = append(roleMessage.Grants, &pb.Grant{
+				Table:  grant.Table,
+				Read:   grant.Read,
+				Create: grant.Create,
+				Update: grant.Update,
+				Delete: grant.Delete,
+			})
+		}
+		message.Roles = append(message.Roles, roleMessage)
+	}
+	return message
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,146 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"testApplication/models"
+)
+
+// MinSimilarity is the trigram similarity a word needs to count as a fuzzy match of a search term.
+const MinSimilarity = 0.3
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Terms splits a search string into lowercase words, punctuation only separates them.
+func Terms(q string) []string {
+	return wordPattern.FindAllString(strings.ToLower(q), -1)
+}
+
+// trigrams follows pg_trgm: the word is padded with two spaces in front and one behind.
+func trigrams(word string) map[string]struct{} {
+
+	runes := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// Similarity is the share of the term trigrams found in the word, from 0 to 1,
+// like word_similarity of pg_trgm it does not penalize a longer word.
+func Similarity(term, word string) float64 {
+
+	termTrigrams, wordTrigrams := trigrams(strings.ToLower(term)), trigrams(strings.ToLower(word))
+
+	common := 0
+	for trigram := range termTrigrams {
+		if _, found := wordTrigrams[trigram]; found {
+			common++
+		}
+	}
+	return float64(common) / float64(len(termTrigrams))
+}
+
+// matches tells whether a word of the searched text matches a term, either by prefix or fuzzily.
+func matches(term, word string) float64 {
+	if strings.HasPrefix(word, term) {
+		return 1
+	}
+	return Similarity(term, word)
+}
+
+// Rank scores a text against the search terms, it is the mean of the best match of every term.
+func Rank(terms []string, text string) float64 {
+
+	if len(terms) == 0 {
+		return 0
+	}
+
+	words := Terms(text)
+	var total float64
+	for _, term := range terms {
+		var best float64
+		for _, word := range words {
+			if score := matches(term, word); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	return total / float64(len(terms))
+}
+
+// Highlight wraps the words matching any of the terms in <mark> tags,
+// the second result tells whether anything was marked.
+func Highlight(text string, terms []string) (string, bool) {
+
+	marked := false
+	highlighted := wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		for _, term := range terms {
+			if matches(term, strings.ToLower(word)) >= MinSimilarity {
+				marked = true
+				return highlightStart + word + highlightStop
+			}
+		}
+		return word
+	})
+	return highlighted, marked
+}
+
+// Highlights highlights every field and keeps the ones with a match.
+func Highlights(fields map[string]string, terms []string) map[string]string {
+
+	highlights := map[string]string{}
+	for field, text := range fields {
+		if highlighted, marked := Highlight(text, terms); marked {
+			highlights[field] = highlighted
+		}
+	}
+	return highlights
+}
+
+// Fragments are the inner trigrams of the terms, a cheap way to find fuzzy candidates
+// in stores without trigram indexes. Terms shorter than three letters are kept whole.
+func Fragments(terms []string) []string {
+
+	var fragments []string
+	seen := map[string]bool{}
+	for _, term := range terms {
+		runes := []rune(term)
+		if len(runes) < 3 {
+			if !seen[term] {
+				seen[term] = true
+				fragments = append(fragments, term)
+			}
+			continue
+		}
+		for i := 0; i+3 <= len(runes); i++ {
+			fragment := string(runes[i : i+3])
+			if !seen[fragment] {
+				seen[fragment] = true
+				fragments = append(fragments, fragment)
+			}
+		}
+	}
+	return fragments
+}
+
+// ClientResult builds a search hit with the highlighted client fields.
+func ClientResult(client models.Client, terms []string, rank float64) models.ClientSearchResult {
+	return models.ClientSearchResult{
+		Client: client,
+		Rank:   rank,
+		Highlights: Highlights(map[string]string{
+			"name":    client.Name,
+			"email":   client.Email,
+			"address": client.Address,
+			"taxId":   client.TaxId,
+		}, terms),
+	}
+}
@@ -0,0 +1,225 @@
+// Package stream pushes client and user changes to long-lived SSE and WebSocket connections. Changes
+// are relayed through Redis, so a connection hears about changes made on every instance, and can
+// resume from the id of the last change it saw.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"testApplication/events"
+	"testApplication/interfaces"
+	"testApplication/redis"
+	"time"
+)
+
+// buffered is how many changes a subscriber may fall behind before it is dropped, it resumes
+// from its last change after reconnecting.
+const buffered = 256
+
+// Message is a change as it is stored in Redis.
+type Message struct {
+	Id    string
+	Event events.Event
+}
+
+type Hub struct {
+	redisConn   *redis.Connection
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub(redisConn *redis.Connection) *Hub {
+	return &Hub{redisConn: redisConn, subscribers: map[*Subscription]struct{}{}}
+}
+
+// forwarded is how many changes may wait for Redis before the writes publishing them block.
+const forwarded = 1024
+
+// Forward publishes the changes made on this instance to all of them, off the publishing request.
+func (hub *Hub) Forward(bus *events.Bus) (unsubscribe func()) {
+
+	return bus.SubscribeQueued(forwarded, func(event events.Event) {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("stream: encoding event %s failed: %s", event.Id, err)
+			return
+		}
+		_, err = hub.redisConn.PublishChange(context.Background(), payload)
+		if err != nil {
+			log.Printf("stream: publishing event %s failed: %s", event.Id, err)
+		}
+	})
+}
+
+// Run relays the changes announced in Redis to the subscribers until ctx is cancelled.
+func (hub *Hub) Run(ctx context.Context) {
+
+	for {
+		changes, err := hub.redisConn.SubscribeChanges(ctx)
+		if err != nil {
+			log.Printf("stream: subscribing to changes failed: %s", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+		for change := range changes {
+			message, err := decode(change)
+			if err != nil {
+				log.Printf("stream: decoding change %s failed: %s", change.Id, err)
+				continue
+			}
+			hub.broadcast(message)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (hub *Hub) broadcast(message Message) {
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for subscription := range hub.subscribers {
+		select {
+		case subscription.live <- message:
+		default:
+			log.Printf("stream: dropping a subscriber %d changes behind", buffered)
+			delete(hub.subscribers, subscription)
+			close(subscription.live)
+		}
+	}
+}
+
+// Subscription receives the changes published after it was made on C, preceded by the ones after
+// lastEventId when that was given. C is closed when the subscriber fell too far behind.
+type Subscription struct {
+	C <-chan Message
+	// Complete is false when lastEventId is older than the kept changes, the subscriber should
+	// reload what it shows instead of relying on the replay.
+	Complete bool
+
+	hub   *Hub
+	live  chan Message
+	done  chan struct{}
+	close sync.Once
+}
+
+func (hub *Hub) Subscribe(ctx context.Context, lastEventId string) (*Subscription, error) {
+
+	subscription := &Subscription{hub: hub, live: make(chan Message, buffered), done: make(chan struct{}), Complete: true}
+
+	// subscribe before reading the replay, a change published in between is then in both and
+	// skipped the second time
+	hub.mu.Lock()
+	hub.subscribers[subscription] = struct{}{}
+	hub.mu.Unlock()
+
+	var replay []Message
+	if lastEventId != "" {
+		changes, complete, err := hub.redisConn.ChangesAfter(ctx, lastEventId)
+		if err != nil {
+			subscription.Close()
+			return nil, err
+		}
+		subscription.Complete = complete
+		for _, change := range changes {
+			message, err := decode(change)
+			if err != nil {
+				log.Printf("stream: decoding change %s failed: %s", change.Id, err)
+				continue
+			}
+			replay = append(replay, message)
+		}
+	}
+
+	out := make(chan Message)
+	subscription.C = out
+	go subscription.relay(out, replay)
+	return subscription, nil
+}
+
+func (subscription *Subscription) relay(out chan<- Message, replay []Message) {
+
+	defer close(out)
+
+	last := ""
+	for _, message := range replay {
+		select {
+		case out <- message:
+			last = message.Id
+		case <-subscription.done:
+			return
+		}
+	}
+	for message := range subscription.live {
+		if last != "" && !redis.ChangeIdLess(last, message.Id) {
+			continue
+		}
+		select {
+		case out <- message:
+		case <-subscription.done:
+			return
+		}
+	}
+}
+
+func (subscription *Subscription) Close() {
+
+	subscription.close.Do(func() {
+		close(subscription.done)
+
+		subscription.hub.mu.Lock()
+		defer subscription.hub.mu.Unlock()
+		if _, ok := subscription.hub.subscribers[subscription]; ok {
+			delete(subscription.hub.subscribers, subscription)
+			close(subscription.live)
+		}
+	})
+}
+
+func decode(change redis.Change) (Message, error) {
+
+	var event events.Event
+	err := json.Unmarshal(change.Payload, &event)
+	return Message{Id: change.Id, Event: event}, err
+}
+
+// refresh is how long the grants of a subscriber are trusted before they are read again, so a
+// revoked grant stops its changes without a reconnect.
+const refresh = time.Minute
+
+// Reader decides which changes a subscriber sees, those of the given tables it has the read grant on.
+type Reader struct {
+	userRepo  interfaces.UserRepo
+	userId    int
+	tables    []string
+	granted   map[string]bool
+	checkedAt time.Time
+}
+
+func NewReader(userRepo interfaces.UserRepo, userId int, tables ...string) *Reader {
+	return &Reader{userRepo: userRepo, userId: userId, tables: tables}
+}
+
+func (reader *Reader) Allowed(ctx context.Context, event events.Event) (bool, error) {
+
+	if time.Since(reader.checkedAt) > refresh {
+		granted := map[string]bool{}
+		for _, table := range reader.tables {
+			grant, err := reader.userRepo.CheckUserGrant(ctx, reader.userId, table, "read")
+			if err != nil {
+				return false, err
+			}
+			granted[table] = grant
+		}
+		reader.granted, reader.checkedAt = granted, time.Now()
+	}
+	return reader.granted[event.Table], nil
+}
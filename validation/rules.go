@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// rules checks the `validate` struct tags of request inputs, fields are reported by their json names.
+var rules = newRules()
+
+func newRules() *validator.Validate {
+
+	rules := validator.New()
+	rules.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	rules.RegisterValidation("notblank", validators.NotBlank)
+	rules.RegisterValidation("httpurl", httpURL)
+	return rules
+}
+
+// Struct applies the declarative rules of an input struct, returns FieldErrors or nil when it is valid.
+func Struct(input interface{}) error {
+
+	err := rules.Struct(input)
+	if err == nil {
+		return nil
+	}
+	failed, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	errs := FieldErrors{}
+	for _, fieldErr := range failed {
+		errs[fieldPath(fieldErr.Namespace())] = message(fieldErr)
+	}
+	return errs
+}
+
+// fieldPath drops the struct names from a namespace like ClientUpdateInput.ClientInput.tags[0],
+// json names start in lower case while the input types and embedded structs do not.
+func fieldPath(namespace string) string {
+
+	var path []string
+	for _, part := range strings.Split(namespace, ".") {
+		if part != "" && !unicode.IsUpper(rune(part[0])) {
+			path = append(path, part)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+func message(fieldErr validator.FieldError) string {
+
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", fieldErr.Param(), unit)
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", fieldErr.Param(), unit)
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "gte":
+		return "must be at least " + fieldErr.Param()
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be in E.164 format, e.g. +14155552671"
+	case "httpurl":
+		return "must be an absolute http or https URL"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "nefield":
+		// the param names the other Go field, report it by its json name
+		return "must differ from " + strings.ToLower(fieldErr.Param()[:1]) + fieldErr.Param()[1:]
+	}
+	return "is invalid (" + fieldErr.Tag() + ")"
+}
+
+func httpURL(field validator.FieldLevel) bool {
+
+	parsed, err := url.Parse(field.Field().String())
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,251 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"testApplication/models"
+	"testApplication/query"
+	"time"
+)
+
+// FieldErrors maps a field name to the reason its value was rejected.
+type FieldErrors map[string]string
+
+func (errs FieldErrors) Error() string {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, errs[field]))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+var e164 = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+func isEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Client checks a client and its custom field values against the current
+// definitions before it is persisted, returns nil when it is valid.
+func Client(client models.Client, definitions []models.CustomField) error {
+
+	errs := FieldErrors{}
+
+	if strings.TrimSpace(client.Name) == "" {
+		errs["name"] = "is required"
+	} else if len(client.Name) > 255 {
+		errs["name"] = "must be at most 255 characters"
+	}
+	if client.Email != "" && !isEmail(client.Email) {
+		errs["email"] = "must be a valid email address"
+	}
+	if client.Phone != "" && !e164.MatchString(client.Phone) {
+		errs["phone"] = "must be in E.164 format, e.g. +14155552671"
+	}
+	if len(client.TaxId) > 64 {
+		errs["taxId"] = "must be at most 64 characters"
+	}
+	if !contains(models.ClientStatuses, client.Status) {
+		errs["status"] = "must be one of " + strings.Join(models.ClientStatuses, ", ")
+	}
+	for i, tag := range client.Tags {
+		if strings.TrimSpace(tag) == "" {
+			errs[fmt.Sprintf("tags[%d]", i)] = "must not be empty"
+		}
+	}
+	customFields(errs, definitions, client.CustomFields)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Contact checks a client contact, returns nil when it is valid.
+func Contact(contact models.Contact) error {
+
+	errs := FieldErrors{}
+
+	if strings.TrimSpace(contact.Name) == "" {
+		errs["name"] = "is required"
+	} else if len(contact.Name) > 255 {
+		errs["name"] = "must be at most 255 characters"
+	}
+	if contact.Email != "" && !isEmail(contact.Email) {
+		errs["email"] = "must be a valid email address"
+	}
+	if contact.Phone != "" && !e164.MatchString(contact.Phone) {
+		errs["phone"] = "must be in E.164 format, e.g. +14155552671"
+	}
+	if len(contact.Position) > 255 {
+		errs["position"] = "must be at most 255 characters"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// User checks a user, password is the new plain text one or nil when it is kept, returns nil when it is valid.
+func User(user models.User, password *string) error {
+
+	errs := FieldErrors{}
+
+	if strings.TrimSpace(user.Name) == "" {
+		errs["name"] = "is required"
+	} else if len(user.Name) > 255 {
+		errs["name"] = "must be at most 255 characters"
+	}
+	if !isEmail(user.Email) {
+		errs["email"] = "must be a valid email address"
+	}
+	if password != nil && len(*password) < 8 {
+		errs["password"] = "must be at least 8 characters"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Activity checks a client activity, returns nil when it is valid.
+func Activity(activity models.Activity) error {
+
+	errs := FieldErrors{}
+
+	if !contains(models.ActivityKinds, activity.Kind) {
+		errs["kind"] = "must be one of " + strings.Join(models.ActivityKinds, ", ")
+	}
+	if strings.TrimSpace(activity.Body) == "" {
+		errs["body"] = "is required"
+	} else if len(activity.Body) > 10000 {
+		errs["body"] = "must be at most 10000 characters"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+const dateLayout = "2006-01-02"
+
+var fieldName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,62}$`)
+
+// CustomField checks a custom field definition, returns nil when it is valid.
+func CustomField(field models.CustomField) error {
+
+	errs := FieldErrors{}
+
+	if !fieldName.MatchString(field.Name) {
+		errs["name"] = "must start with a letter and contain only letters, digits and underscores"
+	}
+	if !contains(models.CustomFieldTypes, field.Type) {
+		errs["type"] = "must be one of " + strings.Join(models.CustomFieldTypes, ", ")
+	}
+	if field.Type == models.CustomFieldEnum && len(field.EnumValues) == 0 {
+		errs["enumValues"] = "are required for enum fields"
+	}
+	if field.Type != models.CustomFieldEnum && len(field.EnumValues) > 0 {
+		errs["enumValues"] = "are allowed only for enum fields"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func customFields(errs FieldErrors, definitions []models.CustomField, values map[string]interface{}) {
+
+	defined := map[string]bool{}
+	for _, definition := range definitions {
+		defined[definition.Name] = true
+		key := "customFields." + definition.Name
+
+		value, ok := values[definition.Name]
+		if !ok || value == nil {
+			if definition.Required {
+				errs[key] = "is required"
+			}
+			continue
+		}
+		if err := checkCustomFieldValue(definition, value); err != nil {
+			errs[key] = err.Error()
+		}
+	}
+
+	for name := range values {
+		if !defined[name] {
+			errs["customFields."+name] = "is not a defined custom field"
+		}
+	}
+}
+
+func checkCustomFieldValue(definition models.CustomField, value interface{}) error {
+
+	switch definition.Type {
+	case models.CustomFieldString:
+		if _, ok := value.(string); !ok {
+			return errors.New("must be a string")
+		}
+	case models.CustomFieldNumber:
+		switch value.(type) {
+		case float64, float32, int, int32, int64:
+		default:
+			return errors.New("must be a number")
+		}
+	case models.CustomFieldBool:
+		if _, ok := value.(bool); !ok {
+			return errors.New("must be a boolean")
+		}
+	case models.CustomFieldDate:
+		date, ok := value.(string)
+		if !ok {
+			return errors.New("must be a date in YYYY-MM-DD format")
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return errors.New("must be a date in YYYY-MM-DD format")
+		}
+	case models.CustomFieldEnum:
+		enum, ok := value.(string)
+		if !ok || !contains(definition.EnumValues, enum) {
+			return errors.New("must be one of " + strings.Join(definition.EnumValues, ", "))
+		}
+	}
+	return nil
+}
+
+// ClientSchema lists the client fields that may be filtered and sorted by,
+// including the custom fields defined at the moment.
+func ClientSchema(definitions []models.CustomField) query.Schema {
+
+	customFields := map[string]query.Field{}
+	for _, definition := range definitions {
+		customFields[definition.Name] = query.Field{Type: query.FieldType(definition.Type), Values: definition.EnumValues}
+	}
+
+	return query.ClientSchema(models.ClientStatuses, customFields)
+}
@@ -0,0 +1,227 @@
+// Package webhooks queues client and user events for the subscribed webhooks and delivers them,
+// signed and retried with exponential backoff until a delivery succeeds or is given up as dead.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"testApplication/events"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"time"
+)
+
+const (
+	IdHeader        = "Webhook-Id"
+	TimestampHeader = "Webhook-Timestamp"
+	SignatureHeader = "Webhook-Signature"
+)
+
+// Sign returns the signature header value of a delivery, the HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the webhook secret. Receivers recompute it and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret for a webhook created without one.
+func NewSecret() (string, error) {
+
+	random := make([]byte, 32)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(random), nil
+}
+
+// queued is how many events may wait to be stored before the writes publishing them block.
+const queued = 1024
+
+// Enqueue subscribes to the bus and queues every event for the webhooks subscribed to its type. The
+// delivery queue is stored, so events survive a restart and are sent by whichever instance claims them.
+// Storing runs off the publishing request.
+func Enqueue(bus *events.Bus, repo interfaces.WebhookRepo) (unsubscribe func()) {
+
+	return bus.SubscribeQueued(queued, func(event events.Event) {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("webhooks: encoding event %s failed: %s", event.Id, err)
+			return
+		}
+		err = repo.EnqueueDeliveries(context.Background(), event.Id, event.Type, payload)
+		if err != nil {
+			log.Printf("webhooks: queueing event %s failed: %s", event.Id, err)
+		}
+	})
+}
+
+type Config struct {
+	// MaxAttempts is how often a delivery is tried before it is dead.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failed attempt, it doubles with every further one up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout limits a single attempt.
+	Timeout   time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Sender delivers queued events.
+type Sender struct {
+	repo   interfaces.WebhookRepo
+	config Config
+	client *http.Client
+}
+
+func NewSender(repo interfaces.WebhookRepo, config Config) *Sender {
+	return &Sender{repo: repo, config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+// Run sends the due deliveries every interval until ctx is cancelled.
+func (sender *Sender) Run(ctx context.Context) {
+
+	if sender.config.Interval <= 0 || sender.config.BatchSize <= 0 || sender.config.MaxAttempts <= 0 {
+		log.Println("webhooks: interval, batch size or max attempts is not set, deliveries disabled")
+		return
+	}
+
+	ticker := time.NewTicker(sender.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		sender.SendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue claims the due deliveries and attempts each of them once, in parallel.
+func (sender *Sender) SendDue(ctx context.Context) {
+
+	// a claim outlives the attempts, a crashed instance leaves its deliveries to the others after it
+	deliveries, err := sender.repo.ClaimDeliveries(ctx, sender.config.BatchSize, 2*sender.config.Timeout+time.Minute)
+	if err != nil {
+		log.Printf("webhooks: claiming deliveries failed: %s", err)
+		return
+	}
+
+	webhooks := map[int]models.Webhook{}
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.WebhookId]; ok {
+			continue
+		}
+		webhooks[delivery.WebhookId], err = sender.repo.GetWebhookById(ctx, delivery.WebhookId)
+		if err != nil {
+			log.Printf("webhooks: reading webhook %d failed: %s", delivery.WebhookId, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		webhook := webhooks[delivery.WebhookId]
+		if webhook.Id == 0 {
+			// deleted meanwhile, its deliveries went with it
+			continue
+		}
+		wg.Add(1)
+		go func(delivery models.WebhookDelivery) {
+			defer wg.Done()
+			err := sender.repo.RecordAttempt(ctx, sender.attempt(ctx, webhook, delivery))
+			if err != nil {
+				log.Printf("webhooks: recording delivery %d failed: %s", delivery.Id, err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// attempt posts the delivery once and returns it updated with the outcome.
+func (sender *Sender) attempt(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) models.WebhookDelivery {
+
+	delivery.Attempts++
+	delivery.LastStatusCode, delivery.LastError = 0, ""
+
+	statusCode, err := sender.post(ctx, webhook, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := time.Now()
+		delivery.Status, delivery.DeliveredAt = models.DeliveryDelivered, &now
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= sender.config.MaxAttempts {
+		delivery.Status = models.DeliveryDead
+		log.Printf("webhooks: delivery %d to webhook %d is dead after %d attempts: %s", delivery.Id, webhook.Id, delivery.Attempts, err)
+		return delivery
+	}
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(sender.backoff(delivery.Attempts))
+	return delivery
+}
+
+func (sender *Sender) post(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "testApplication-webhooks")
+	req.Header.Set(IdHeader, delivery.EventId)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := sender.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// backoff waits BaseDelay·2^(attempts-1), capped at MaxDelay, and adds up to a quarter of jitter
+// so webhooks failing together do not retry together.
+func (sender *Sender) backoff(attempts int) time.Duration {
+
+	delay := sender.config.BaseDelay
+	for i := 1; i < attempts && delay < sender.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if sender.config.MaxDelay > 0 && delay > sender.config.MaxDelay {
+		delay = sender.config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(mathrand.Int63n(int64(delay)/4+1))
+}
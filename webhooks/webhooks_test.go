@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testApplication/interfaces"
+	"testApplication/models"
+	"testing"
+	"time"
+)
+
+const secret = "whsec_test"
+
+// fakeRepo keeps one webhook and its deliveries in memory. Every pending delivery is claimed
+// whenever it is due or not, so a test can run the attempts back to back.
+type fakeRepo struct {
+	interfaces.WebhookRepo
+	webhook    models.Webhook
+	mu         sync.Mutex
+	deliveries map[int64]models.WebhookDelivery
+}
+
+func newFakeRepo(url string) *fakeRepo {
+	return &fakeRepo{
+		webhook: models.Webhook{Id: 1, Url: url, Secret: secret, Active: true},
+		deliveries: map[int64]models.WebhookDelivery{
+			1: {Id: 1, WebhookId: 1, EventId: "event-1", EventType: "client.created", Payload: []byte(`{"id":"event-1"}`), Status: models.DeliveryPending},
+		},
+	}
+}
+
+func (repo *fakeRepo) GetWebhookById(ctx context.Context, id int) (models.Webhook, error) {
+	return repo.webhook, nil
+}
+
+func (repo *fakeRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var claimed []models.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if delivery.Status == models.DeliveryPending && len(claimed) < limit {
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (repo *fakeRepo) RecordAttempt(ctx context.Context, delivery models.WebhookDelivery) error {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.deliveries[delivery.Id] = delivery
+	return nil
+}
+
+func (repo *fakeRepo) delivery() models.WebhookDelivery {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.deliveries[1]
+}
+
+func newTestSender(repo *fakeRepo) *Sender {
+	return NewSender(repo, Config{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Timeout:     5 * time.Second,
+		Interval:    time.Second,
+		BatchSize:   10,
+	})
+}
+
+func receiver(t *testing.T, status int, received chan<- *http.Request) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if received != nil {
+			received <- r
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSignatureHeaders(t *testing.T) {
+
+	received := make(chan *http.Request, 1)
+	server := receiver(t, http.StatusOK, received)
+	repo := newFakeRepo(server.URL)
+
+	before := time.Now().Unix()
+	newTestSender(repo).SendDue(context.Background())
+	after := time.Now().Unix()
+
+	r := <-received
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != `{"id":"event-1"}` {
+		t.Errorf("body = %s", body)
+	}
+	if got := r.Header.Get(IdHeader); got != "event-1" {
+		t.Errorf("%s = %q, want event-1", IdHeader, got)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s: %s", TimestampHeader, err)
+	}
+	if timestamp < before || timestamp > after {
+		t.Errorf("%s = %d, want between %d and %d", TimestampHeader, timestamp, before, after)
+	}
+	if got, want := r.Header.Get(SignatureHeader), Sign(secret, timestamp, body); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+}
+
+func TestDelivered(t *testing.T) {
+
+	server := receiver(t, http.StatusNoContent, nil)
+	repo := newFakeRepo(server.URL)
+
+	newTestSender(repo).SendDue(context.Background())
+
+	delivery := repo.delivery()
+	if delivery.Status != models.DeliveryDelivered {
+		t.Fatalf("status = %s, want %s", delivery.Status, models.DeliveryDelivered)
+	}
+	if delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusNoContent || delivery.DeliveredAt == nil {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+
+	server := receiver(t, http.StatusServiceUnavailable, nil)
+	repo := newFakeRepo(server.URL)
+	sender := newTestSender(repo)
+
+	var lastDelay time.Duration
+	for attempt := 1; attempt < sender.config.MaxAttempts; attempt++ {
+		sentAt := time.Now()
+		sender.SendDue(context.Background())
+
+		delivery := repo.delivery()
+		if delivery.Status != models.DeliveryPending || delivery.Attempts != attempt {
+			t.Fatalf("attempt %d: status = %s, attempts = %d", attempt, delivery.Status, delivery.Attempts)
+		}
+		if delivery.LastStatusCode != http.StatusServiceUnavailable || delivery.LastError == "" {
+			t.Errorf("attempt %d: last status %d, last error %q", attempt, delivery.LastStatusCode, delivery.LastError)
+		}
+		delay := delivery.NextAttemptAt.Sub(sentAt)
+		if delay <= lastDelay {
+			t.Errorf("attempt %d: next attempt in %s, not later than the %s before", attempt, delay, lastDelay)
+		}
+		lastDelay = delay
+	}
+}
+
+func TestDeadAfterMaxAttempts(t *testing.T) {
+
+	server := receiver(t, http.StatusInternalServerError, nil)
+	repo := newFakeRepo(server.URL)
+	sender := newTestSender(repo)
+
+	for attempt := 0; attempt < sender.config.MaxAttempts+1; attempt++ {
+		sender.SendDue(context.Background())
+	}
+
+	delivery := repo.delivery()
+	if delivery.Status != models.DeliveryDead {
+		t.Fatalf("status = %s, want %s", delivery.Status, models.DeliveryDead)
+	}
+	if delivery.Attempts != sender.config.MaxAttempts {
+		t.Errorf("attempts = %d, want %d", delivery.Attempts, sender.config.MaxAttempts)
+	}
+}